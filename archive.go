@@ -0,0 +1,274 @@
+package validate
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// archiveExtensions are the file extensions treated as archives when
+// checking for nested archives.
+var archiveExtensions = map[string]bool{
+	".zip": true, ".jar": true, ".rar": true, ".7z": true, ".tar": true,
+	".gz": true, ".tgz": true, ".bz2": true, ".xz": true, ".zst": true,
+}
+
+// archiveSignatures are the magic numbers of common archive formats.
+var archiveSignatures = [][]byte{
+	[]byte("PK\x03\x04"),
+	[]byte("\x1f\x8b"),
+	[]byte("Rar!\x1a\x07"),
+	[]byte("7z\xbc\xaf\x27\x1c"),
+	[]byte("BZh"),
+	[]byte("\xfd7zXZ\x00"),
+	[]byte("\x28\xb5\x2f\xfd"),
+}
+
+// The limits that Zip applies to archives when the Options map does
+// not set `max_size` or `max_entries`, so that an archive that
+// expands to far more than was uploaded is never read in full.
+const (
+	DefaultZipMaxSize    int64 = 1 << 30
+	DefaultZipMaxEntries       = 10000
+)
+
+// pdfHeader matches the version comment that starts a PDF file.
+var pdfHeader = regexp.MustCompile(`^%PDF-(\d)\.(\d)`)
+
+// openZip opens an uploaded file as a zip archive. The archive is
+// read directly from the multipart.File, which is never copied to
+// disk.
+func openZip(fh *multipart.FileHeader) (*zip.Reader, multipart.File, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	zr, err := zip.NewReader(f, fh.Size)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	return zr, f, nil
+}
+
+// unsafePath reports whether an archive entry name could be used to
+// write outside of the directory the archive is extracted into.
+func unsafePath(name string) bool {
+	name = strings.ReplaceAll(name, `\`, "/")
+
+	// Absolute paths, including Windows drive letters.
+	if strings.HasPrefix(name, "/") || (len(name) >= 2 && name[1] == ':') {
+		return true
+	}
+
+	clean := path.Clean(name)
+	return clean == ".." || strings.HasPrefix(clean, "../")
+}
+
+// isArchive reports whether a zip entry looks like an archive, by
+// its extension or by its content.
+func isArchive(f *zip.File) bool {
+	if archiveExtensions[strings.ToLower(path.Ext(f.Name))] {
+		return true
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return false
+	}
+	defer rc.Close()
+
+	head := make([]byte, 8)
+	n, _ := io.ReadFull(rc, head)
+	for _, sig := range archiveSignatures {
+		if bytes.HasPrefix(head[:n], sig) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Zip returns an error if any file uploaded for the parameter is
+// not a zip archive, or contains an entry whose name would be
+// extracted outside of the target directory, such as `../x`.
+//
+// The Options map can also set:
+//   - `max_entries`: the maximum number of entries in the archive,
+//     which defaults to DefaultZipMaxEntries.
+//   - `max_size`: the maximum total uncompressed size in bytes, which
+//     defaults to DefaultZipMaxSize. The entries are decompressed,
+//     without being stored, to make sure their headers do not
+//     understate their size.
+//   - `allow_nested`: when true, archives within the archive are
+//     allowed. They are rejected by default.
+var Zip CheckFunc = func(r *http.Request, param string, o Options) error {
+	return zipField(r.Context(), requestField(r, param, o))
+}
+
+func zipField(ctx context.Context, f Field) error {
+	maxEntries, ok := f.Options["max_entries"].(int)
+	if !ok {
+		maxEntries = DefaultZipMaxEntries
+	}
+	maxSize, ok := sizeOption(f.Options, "max_size")
+	if !ok {
+		maxSize = DefaultZipMaxSize
+	}
+	allowNested, _ := f.Options["allow_nested"].(bool)
+
+	for _, fh := range f.Files {
+		if err := checkZip(fh, maxEntries, maxSize, allowNested); err != nil {
+			return fmt.Errorf("%s %s", f.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func checkZip(fh *multipart.FileHeader, maxEntries int, maxSize int64, allowNested bool) error {
+	zr, f, err := openZip(fh)
+	if err != nil {
+		return fmt.Errorf("must be a zip archive")
+	}
+	defer f.Close()
+
+	if len(zr.File) > maxEntries {
+		return fmt.Errorf("cannot contain more than %d files", maxEntries)
+	}
+
+	var total uint64
+	for _, entry := range zr.File {
+		if unsafePath(entry.Name) {
+			return fmt.Errorf("contains an unsafe file path")
+		}
+
+		if !allowNested && !entry.FileInfo().IsDir() && isArchive(entry) {
+			return fmt.Errorf("cannot contain other archives")
+		}
+
+		total += entry.UncompressedSize64
+		if total > uint64(maxSize) {
+			return fmt.Errorf("cannot be larger than %s when extracted", formatSize(maxSize))
+		}
+	}
+
+	// The sizes in the headers are set by whoever made the archive,
+	// so check how much data the entries really expand to.
+	remaining := maxSize
+	for _, entry := range zr.File {
+		rc, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("must be a zip archive")
+		}
+
+		n, err := io.Copy(io.Discard, io.LimitReader(rc, remaining+1))
+		rc.Close()
+		if n > remaining {
+			return fmt.Errorf("cannot be larger than %s when extracted", formatSize(maxSize))
+		}
+		if err != nil {
+			return fmt.Errorf("must be a zip archive")
+		}
+		remaining -= n
+	}
+
+	return nil
+}
+
+// PDF returns an error if any file uploaded for the parameter does
+// not start with a PDF header and end with an end-of-file marker.
+// The `min_version` and `max_version` keys in the Options map, such
+// as "1.4", limit the PDF versions that are accepted.
+var PDF CheckFunc = func(r *http.Request, param string, o Options) error {
+	return pdfField(r.Context(), requestField(r, param, o))
+}
+
+func pdfField(ctx context.Context, f Field) error {
+	for _, fh := range f.Files {
+		version, err := pdfVersion(fh)
+		if err != nil {
+			return fmt.Errorf("%s must be a PDF document", f.Name)
+		}
+
+		if min, ok := f.Options["min_version"].(string); ok && compareVersions(version, min) < 0 {
+			return fmt.Errorf("%s must be at least PDF version %s", f.Name, min)
+		}
+
+		if max, ok := f.Options["max_version"].(string); ok && compareVersions(version, max) > 0 {
+			return fmt.Errorf("%s cannot be newer than PDF version %s", f.Name, max)
+		}
+	}
+
+	return nil
+}
+
+// pdfVersion reads the version from the header of an uploaded PDF,
+// after checking that the file ends with a `%%EOF` marker.
+func pdfVersion(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 8)
+	if _, err := io.ReadFull(f, head); err != nil {
+		return "", err
+	}
+
+	m := pdfHeader.FindSubmatch(head)
+	if m == nil {
+		return "", fmt.Errorf("missing PDF header")
+	}
+
+	// The end-of-file marker must appear within the last 1024 bytes.
+	tailSize := int64(1024)
+	if fh.Size < tailSize {
+		tailSize = fh.Size
+	}
+
+	tail := make([]byte, tailSize)
+	if _, err := f.ReadAt(tail, fh.Size-tailSize); err != nil && err != io.EOF {
+		return "", err
+	}
+
+	if !bytes.Contains(tail, []byte("%%EOF")) {
+		return "", fmt.Errorf("missing PDF end-of-file marker")
+	}
+
+	return string(m[1]) + "." + string(m[2]), nil
+}
+
+// compareVersions compares two major.minor version strings.
+func compareVersions(a string, b string) int {
+	as, bs := strings.SplitN(a, ".", 2), strings.SplitN(b, ".", 2)
+	for i := 0; i < 2; i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,140 @@
+package validate
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// makeZip returns a zip archive containing the given files.
+func makeZip(entries map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for name, content := range entries {
+		f, _ := w.Create(name)
+		f.Write([]byte(content))
+	}
+	w.Close()
+
+	return buf.Bytes()
+}
+
+func TestArchiveRules(t *testing.T) {
+	ok := makeZip(map[string]string{"a.txt": "a", "dir/b.txt": "b"})
+	large := makeZip(map[string]string{"big.txt": strings.Repeat("a", 4096)})
+
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\ntrailer\n<<>>\n%%EOF\n")
+	pdf17 := []byte("%PDF-1.7\n%%EOF")
+
+	rules := []struct {
+		Check   CheckFunc
+		Passes  [][]byte
+		Fails   [][]byte
+		Options Options
+	}{
+		{
+			Zip,
+			[][]byte{ok, large},
+			[][]byte{
+				[]byte("not a zip"),
+				makeZip(map[string]string{"../../etc/passwd": "x"}),
+				makeZip(map[string]string{`..\evil.exe`: "x"}),
+				makeZip(map[string]string{"/etc/passwd": "x"}),
+				makeZip(map[string]string{"C:/Windows/evil.exe": "x"}),
+				makeZip(map[string]string{"inner.zip": "x"}),
+				makeZip(map[string]string{"inner.bin": string(ok)}),
+			},
+			nil,
+		},
+		{
+			Zip,
+			[][]byte{ok, makeZip(map[string]string{"inner.zip": string(ok)})},
+			[][]byte{large, makeZip(map[string]string{"a": "", "b": "", "c": ""})},
+			Options{"max_entries": 2, "max_size": 1 << 10, "allow_nested": true},
+		},
+		{
+			PDF,
+			[][]byte{pdf, pdf17},
+			[][]byte{[]byte("not a pdf"), []byte("%PDF-1.4\ntruncated"), []byte("%PDF-x.y\n%%EOF")},
+			nil,
+		},
+		{
+			PDF,
+			[][]byte{pdf},
+			[][]byte{pdf17},
+			Options{"min_version": "1.3", "max_version": "1.6"},
+		},
+	}
+
+	for _, rule := range rules {
+		for _, content := range rule.Passes {
+			r := uploadRequest(upload{"file", "file", content})
+			msgs, _ := Check(r, Rule{Param: "file", Check: rule.Check, Options: rule.Options})
+			if len(msgs) > 0 {
+				fmt.Println("Got an error, expected none:", msgs["file"])
+				t.FailNow()
+			}
+		}
+
+		for i, content := range rule.Fails {
+			r := uploadRequest(upload{"file", "file", content})
+			msgs, _ := Check(r, Rule{Param: "file", Check: rule.Check, Options: rule.Options})
+			if len(msgs) == 0 {
+				fmt.Println("Expected an error, didn't get one for case", i)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestZipChecksRealUncompressedSize(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, _ := w.CreateHeader(&zip.FileHeader{Name: "bomb.txt", Method: zip.Store})
+	f.Write(bytes.Repeat([]byte("a"), 4096))
+	w.Close()
+
+	// Understate the uncompressed size in the central directory.
+	content := buf.Bytes()
+	cd := bytes.Index(content, []byte("PK\x01\x02"))
+	binary.LittleEndian.PutUint32(content[cd+24:], 1)
+
+	r := uploadRequest(upload{"file", "bomb.zip", content})
+	msgs, _ := Check(r, Rule{Param: "file", Check: Zip, Options: Options{"max_size": 1 << 10}})
+	if len(msgs) == 0 {
+		fmt.Println("expected an archive that understates its size to fail")
+		t.FailNow()
+	}
+}
+
+func TestZipLimitsArchivesByDefault(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, _ := w.CreateHeader(&zip.FileHeader{Name: "bomb.txt", Method: zip.Store})
+	f.Write([]byte("a"))
+	w.Close()
+
+	// Claim that the entry expands to almost 4GB.
+	content := buf.Bytes()
+	cd := bytes.Index(content, []byte("PK\x01\x02"))
+	binary.LittleEndian.PutUint32(content[cd+24:], 0xfffffffe)
+
+	r := uploadRequest(upload{"file", "bomb.zip", content})
+	if msgs, _ := Check(r, Rule{Param: "file", Check: Zip}); len(msgs) == 0 || !strings.Contains(msgs["file"][0], "when extracted") {
+		fmt.Println("expected an archive larger than DefaultZipMaxSize to fail, got", msgs)
+		t.FailNow()
+	}
+
+	entries := make(map[string]string, DefaultZipMaxEntries+1)
+	for i := 0; i <= DefaultZipMaxEntries; i++ {
+		entries[fmt.Sprintf("%d.txt", i)] = ""
+	}
+	r = uploadRequest(upload{"file", "many.zip", makeZip(entries)})
+	if msgs, _ := Check(r, Rule{Param: "file", Check: Zip}); len(msgs) == 0 || !strings.Contains(msgs["file"][0], "more than") {
+		fmt.Println("expected an archive with more than DefaultZipMaxEntries entries to fail, got", msgs)
+		t.FailNow()
+	}
+}
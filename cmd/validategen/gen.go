@@ -0,0 +1,650 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gostalt/validate"
+)
+
+// header marks generated files, which are skipped when the package
+// is read so that they can be regenerated.
+const header = "// Code generated by validategen. DO NOT EDIT."
+
+// generate returns the package's name and the generated source for
+// the named types, or for every struct with `validate` tags.
+func generate(dir string, names []string) (string, []byte, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", nil, err
+		}
+		if bytes.HasPrefix(src, []byte(header)) {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), src, 0)
+		if err != nil {
+			return "", nil, err
+		}
+		files = append(files, f)
+	}
+
+	// Errors are ignored, so that packages that call the methods that
+	// are about to be generated can be read.
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(bp.ImportPath, fset, files, nil)
+
+	g := &generator{
+		fset:    fset,
+		pkg:     pkg,
+		imports: map[string]string{},
+		helpers: map[*types.Named]*helper{},
+	}
+
+	explicit := len(names) > 0
+	if !explicit {
+		names = pkg.Scope().Names()
+	}
+
+	var body bytes.Buffer
+	for _, name := range names {
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			if explicit {
+				return "", nil, fmt.Errorf("type %s does not exist", name)
+			}
+			continue
+		}
+
+		named, ok := obj.Type().(*types.Named)
+		if _, isStruct := obj.Type().Underlying().(*types.Struct); !ok || !isStruct || obj.IsAlias() {
+			if explicit {
+				return "", nil, fmt.Errorf("%s is not a struct type", name)
+			}
+			continue
+		}
+
+		src, err := g.validator(named)
+		if err != nil {
+			return "", nil, err
+		}
+		if src == "" && explicit {
+			return "", nil, fmt.Errorf("%s does not have any validate tags", name)
+		}
+		body.WriteString(src)
+	}
+
+	for _, h := range g.order {
+		body.Write(h.src)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s\n\npackage %s\n\nimport (\n", header, pkg.Name())
+	g.imports["context"] = "context"
+	g.imports["net/url"] = "url"
+	g.imports["github.com/gostalt/validate"] = "validate"
+	if strings.Contains(body.String(), "strconv.") {
+		g.imports["strconv"] = "strconv"
+	}
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, std := range []bool{true, false} {
+		out.WriteString("\n")
+		for _, path := range paths {
+			first, _, _ := strings.Cut(path, "/")
+			if strings.Contains(first, ".") == std {
+				continue
+			}
+			if name := g.imports[path]; name != filepath.Base(path) {
+				fmt.Fprintf(&out, "%s ", name)
+			}
+			fmt.Fprintf(&out, "%q\n", path)
+		}
+	}
+	out.WriteString(")\n")
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to format the generated code: %w", err)
+	}
+
+	return pkg.Name(), src, nil
+}
+
+type generator struct {
+	fset    *token.FileSet
+	pkg     *types.Package
+	imports map[string]string
+	helpers map[*types.Named]*helper
+	order   []*helper
+	vars    int
+}
+
+// validator returns the rules and Validate method for a struct, or
+// nothing if it does not have any `validate` tags.
+func (g *generator) validator(named *types.Named) (string, error) {
+	pairs, err := g.rules("", named.Underlying().(*types.Struct), 0)
+	if err != nil {
+		return "", err
+	}
+	if len(pairs) == 0 {
+		return "", nil
+	}
+
+	name := named.Obj().Name()
+	if obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), false, g.pkg, "Validate"); obj != nil {
+		return "", fmt.Errorf("%s: %s already has a Validate field or method", g.fset.Position(named.Obj().Pos()), name)
+	}
+
+	var b bytes.Buffer
+	rulesVar := "validate" + exportName(name) + "Rules"
+	// The rules are compiled when the package is initialised, so a
+	// misconfigured rule, such as a regex whose pattern does not
+	// compile, panics once, like a tag that cannot be parsed.
+	fmt.Fprintf(&b, "\nvar %s = validate.MustCompile(validate.MustParseTags(\n", rulesVar)
+	for i := 0; i < len(pairs); i += 2 {
+		fmt.Fprintf(&b, "%q, %q,\n", pairs[i], pairs[i+1])
+	}
+	b.WriteString(")...)\n")
+
+	fmt.Fprintf(&b, "\n// Validate checks the %s against the rules in its validate tags.\n", name)
+	fmt.Fprintf(&b, "func (s %s) Validate() (validate.Message, error) {\n", name)
+	b.WriteString("values, raw, lists := make(url.Values), make(map[string]interface{}), make(map[string]bool)\n")
+	if err := g.helperCall(&b, named, `""`, "s"); err != nil {
+		return "", err
+	}
+	// Rules can still fail while they are checked, such as when a
+	// DNS lookup fails, so the error is returned along with
+	// ValidationFailed.
+	fmt.Fprintf(&b, "return %s.ValidateData(context.Background(), validate.Flattened{Values: values, Raw: raw, Lists: lists})\n}\n", rulesVar)
+
+	return b.String(), nil
+}
+
+// rules returns the parameters and tags of a struct's fields, as
+// pairs, in the same order as validate.RulesFor.
+func (g *generator) rules(prefix string, st *types.Struct, depth int) ([]string, error) {
+	if depth > 32 {
+		return nil, fmt.Errorf("%s is nested too deeply; recursive types cannot be validated", prefix)
+	}
+
+	var pairs []string
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+		if skipField(field) {
+			continue
+		}
+
+		name, tagged := jsonName(field, tag)
+		if name == "-" {
+			continue
+		}
+
+		ft := field.Type()
+		for {
+			p, ok := ft.(*types.Pointer)
+			if !ok {
+				break
+			}
+			ft = p.Elem()
+		}
+		nested, isStruct := ft.Underlying().(*types.Struct)
+
+		if field.Anonymous() && !tagged && isStruct {
+			embedded, err := g.rules(prefix, nested, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, embedded...)
+			continue
+		}
+
+		param := join(prefix, name)
+		if tag, ok := tag.Lookup("validate"); ok {
+			if _, err := validate.ParseTag(param, tag); err != nil {
+				return nil, fmt.Errorf("%s: field %s: %w", g.fset.Position(field.Pos()), field.Name(), err)
+			}
+			pairs = append(pairs, param, tag)
+		}
+
+		if isStruct && !g.marshals(ft) {
+			fieldPairs, err := g.rules(param, nested, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, fieldPairs...)
+		}
+	}
+
+	return pairs, nil
+}
+
+// skipField reports whether a field is skipped, as it is by reflection
+// when it is unexported, unless it is an embedded struct.
+func skipField(field *types.Var) bool {
+	_, isStruct := field.Type().Underlying().(*types.Struct)
+	return !field.Exported() && !(field.Anonymous() && isStruct)
+}
+
+// jsonName returns the name that encoding/json would give a field, and
+// whether it came from a tag.
+func jsonName(field *types.Var, tag reflect.StructTag) (string, bool) {
+	name := tag.Get("json")
+	if name == "-" {
+		return "-", true
+	}
+
+	name, _, _ = strings.Cut(name, ",")
+	if name != "" {
+		return name, true
+	}
+
+	return field.Name(), false
+}
+
+func join(name string, field string) string {
+	if name == "" {
+		return field
+	}
+
+	return name + "." + field
+}
+
+func exportName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// helper is a function that flattens a named struct type.
+type helper struct {
+	name   string
+	src    []byte
+	done   bool
+	called bool
+}
+
+// helperCall writes a call to the function that flattens a named
+// struct type, generating the function if needed. Nothing is written
+// if the struct has no fields that can be flattened.
+func (g *generator) helperCall(b *bytes.Buffer, named *types.Named, prefix string, val string) error {
+	h, ok := g.helpers[named]
+	if !ok {
+		if named.TypeArgs().Len() > 0 {
+			return fmt.Errorf("generic type %s cannot be validated", named)
+		}
+
+		obj := named.Obj()
+		h = &helper{name: "validateFlatten"}
+		if obj.Pkg() != g.pkg {
+			h.name += exportName(obj.Pkg().Name())
+		}
+		h.name += exportName(obj.Name())
+		g.helpers[named] = h
+		g.order = append(g.order, h)
+
+		var fields bytes.Buffer
+		st := named.Underlying().(*types.Struct)
+		if err := g.fields(&fields, "prefix", "s", st); err != nil {
+			return err
+		}
+
+		if fields.Len() > 0 || h.called {
+			var src bytes.Buffer
+			fmt.Fprintf(&src, "\nfunc %s(values url.Values, raw map[string]interface{}, lists map[string]bool, prefix string, s %s) {\n", h.name, g.typeString(named))
+			src.Write(fields.Bytes())
+			src.WriteString("}\n")
+			h.src = src.Bytes()
+		}
+		h.done = true
+	}
+
+	// A recursive type calls its own function before it is done.
+	if h.done && h.src == nil {
+		return nil
+	}
+
+	h.called = true
+	fmt.Fprintf(b, "%s(values, raw, lists, %s, %s)\n", h.name, prefix, val)
+	return nil
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+func (g *generator) tmp() string {
+	g.vars++
+	return "v" + strconv.Itoa(g.vars)
+}
+
+// cat concatenates two string expressions, joining literals.
+func cat(a string, b string) string {
+	if a == `""` {
+		return b
+	}
+
+	as := strings.Split(a, " + ")
+	bs := strings.Split(b, " + ")
+	x, errA := strconv.Unquote(as[len(as)-1])
+	y, errB := strconv.Unquote(bs[0])
+	if errA == nil && errB == nil {
+		as[len(as)-1] = strconv.Quote(x + y)
+		bs = bs[1:]
+	}
+
+	return strings.Join(append(as, bs...), " + ")
+}
+
+// deref dereferences a pointer expression.
+func deref(val string) string {
+	return "*" + val
+}
+
+// sel returns an expression that a selector can follow.
+func sel(val string) string {
+	if strings.HasPrefix(val, "*") {
+		return "(" + val + ")"
+	}
+
+	return val
+}
+
+// ifNotNil writes inner inside a nil check of the pointer expression,
+// if inner writes anything.
+func (g *generator) ifNotNil(b *bytes.Buffer, val string, inner func(b *bytes.Buffer, val string) error) error {
+	v := val
+	if !token.IsIdentifier(val) {
+		v = g.tmp()
+	}
+
+	var body bytes.Buffer
+	if err := inner(&body, deref(v)); err != nil {
+		return err
+	}
+	if body.Len() == 0 {
+		return nil
+	}
+
+	if v == val {
+		fmt.Fprintf(b, "if %s != nil {\n", v)
+	} else {
+		fmt.Fprintf(b, "if %s := %s; %s != nil {\n", v, val, v)
+	}
+	b.Write(body.Bytes())
+	b.WriteString("}\n")
+	return nil
+}
+
+// fields writes the code that flattens each field of a struct, whose
+// names start with the prefix expression.
+func (g *generator) fields(b *bytes.Buffer, prefix string, val string, st *types.Struct) error {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if skipField(field) {
+			continue
+		}
+
+		name, tagged := jsonName(field, reflect.StructTag(st.Tag(i)))
+		if name == "-" {
+			continue
+		}
+
+		if field.Pkg() != g.pkg && !field.Exported() {
+			return fmt.Errorf("%s: embedded field %s cannot be read outside of its package", g.fset.Position(field.Pos()), field.Name())
+		}
+
+		fieldVal := sel(val) + "." + field.Name()
+
+		if field.Anonymous() && !tagged {
+			// Embedded structs are promoted, unless they are nil.
+			ft := field.Type()
+			if p, ok := ft.(*types.Pointer); ok {
+				if _, ok := p.Elem().Underlying().(*types.Struct); ok {
+					err := g.ifNotNil(b, fieldVal, func(b *bytes.Buffer, val string) error {
+						return g.promote(b, prefix, val, p.Elem())
+					})
+					if err != nil {
+						return err
+					}
+					continue
+				}
+			} else if _, ok := ft.Underlying().(*types.Struct); ok {
+				if err := g.promote(b, prefix, fieldVal, ft); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if err := g.walk(b, cat(prefix, strconv.Quote(name)), fieldVal, field.Type()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// promote flattens the fields of an embedded struct into its parent.
+func (g *generator) promote(b *bytes.Buffer, prefix string, val string, t types.Type) error {
+	if named, ok := t.(*types.Named); ok {
+		return g.helperCall(b, named, prefix, val)
+	}
+
+	return g.fields(b, prefix, val, t.Underlying().(*types.Struct))
+}
+
+// walk writes the code that flattens a value with the name given by
+// the name expression, in the same way as validate.Validate.
+func (g *generator) walk(b *bytes.Buffer, name string, val string, t types.Type) error {
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		return g.ifNotNil(b, val, func(b *bytes.Buffer, val string) error {
+			return g.walk(b, name, val, u.Elem())
+		})
+	case *types.Interface:
+		return fmt.Errorf("%s has an interface type, which cannot be validated without reflection", name)
+	}
+
+	if g.scalar(b, name, val, t) {
+		g.setRaw(b, name, val)
+		return nil
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Slice, *types.Array:
+		g.setRaw(b, name, val)
+
+		var elem types.Type
+		if s, ok := u.(*types.Slice); ok {
+			elem = s.Elem()
+			if basic, ok := elem.Underlying().(*types.Basic); ok && basic.Kind() == types.Uint8 {
+				fmt.Fprintf(b, "values.Add(%s, string(%s))\n", name, val)
+				return nil
+			}
+		} else {
+			elem = u.(*types.Array).Elem()
+		}
+
+		n, i, e := g.tmp(), g.tmp(), g.tmp()
+
+		// Elements that are scalars, once dereferenced, are added to
+		// the slice's values. Others are named by their index.
+		var add func(b *bytes.Buffer, val string, t types.Type) (bool, error)
+		add = func(b *bytes.Buffer, val string, t types.Type) (bool, error) {
+			p, ok := t.Underlying().(*types.Pointer)
+			if !ok {
+				return g.scalar(b, n, val, t), nil
+			}
+
+			scalar := false
+			err := g.ifNotNil(b, val, func(b *bytes.Buffer, val string) (err error) {
+				scalar, err = add(b, val, p.Elem())
+				return err
+			})
+			return scalar, err
+		}
+
+		var loop bytes.Buffer
+		scalar, err := add(&loop, e, elem)
+		if err != nil {
+			return err
+		}
+		if scalar {
+			i = "_"
+		} else {
+			loop.Reset()
+			if err := g.walk(&loop, cat(n, `"." + strconv.Itoa(`+i+`)`), e, elem); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(b, "%s := %s\n", n, name)
+		fmt.Fprintf(b, "lists[%s] = true\n", n)
+		fmt.Fprintf(b, "if _, ok := values[%s]; !ok {\nvalues[%s] = []string{}\n}\n", n, n)
+		if loop.Len() > 0 {
+			fmt.Fprintf(b, "for %s, %s := range %s {\n", i, e, val)
+			b.Write(loop.Bytes())
+			b.WriteString("}\n")
+		}
+	case *types.Map:
+		if key, ok := u.Key().Underlying().(*types.Basic); !ok || key.Kind() != types.String {
+			return nil
+		}
+		g.setRaw(b, name, val)
+
+		n, k, e := g.tmp(), g.tmp(), g.tmp()
+		key := k
+		if !types.Identical(u.Key(), types.Typ[types.String]) {
+			key = "string(" + k + ")"
+		}
+
+		var loop bytes.Buffer
+		if err := g.walk(&loop, cat(n, `"." + `+key), e, u.Elem()); err != nil {
+			return err
+		}
+		if loop.Len() > 0 {
+			fmt.Fprintf(b, "%s := %s\n", n, name)
+			fmt.Fprintf(b, "for %s, %s := range %s {\n", k, e, val)
+			b.Write(loop.Bytes())
+			b.WriteString("}\n")
+		}
+	case *types.Struct:
+		g.setRaw(b, name, val)
+		if named, ok := t.(*types.Named); ok {
+			return g.helperCall(b, named, cat(name, `"."`), val)
+		}
+
+		n := g.tmp()
+		var fields bytes.Buffer
+		if err := g.fields(&fields, n, val, u); err != nil {
+			return err
+		}
+		if fields.Len() > 0 {
+			fmt.Fprintf(b, "%s := %s\n", n, cat(name, `"."`))
+			b.Write(fields.Bytes())
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) setRaw(b *bytes.Buffer, name string, val string) {
+	fmt.Fprintf(b, "raw[%s] = %s\n", name, val)
+}
+
+var (
+	textMarshaler = types.NewInterfaceType([]*types.Func{
+		types.NewFunc(token.NoPos, nil, "MarshalText", types.NewSignatureType(nil, nil, nil, nil,
+			types.NewTuple(
+				types.NewVar(token.NoPos, nil, "", types.NewSlice(types.Typ[types.Byte])),
+				types.NewVar(token.NoPos, nil, "", types.Universe.Lookup("error").Type()),
+			), false)),
+	}, nil).Complete()
+	stringer = types.NewInterfaceType([]*types.Func{
+		types.NewFunc(token.NoPos, nil, "String", types.NewSignatureType(nil, nil, nil, nil,
+			types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.String])), false)),
+	}, nil).Complete()
+)
+
+// marshals reports whether a type is converted to a single value by
+// its MarshalText or String method.
+func (g *generator) marshals(t types.Type) bool {
+	return types.Implements(t, textMarshaler) || types.Implements(t, stringer)
+}
+
+// scalar writes the code that adds a value that is not made up of
+// other values, and reports whether the type is one.
+func (g *generator) scalar(b *bytes.Buffer, name string, val string, t types.Type) bool {
+	if types.Implements(t, textMarshaler) {
+		fmt.Fprintf(b, "if text, err := %s.MarshalText(); err == nil {\nvalues.Add(%s, string(text))\n}\n", sel(val), name)
+		return true
+	}
+	if types.Implements(t, stringer) {
+		fmt.Fprintf(b, "values.Add(%s, %s.String())\n", name, sel(val))
+		return true
+	}
+
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+
+	exact := types.Identical(t, basic)
+	var expr string
+	switch info := basic.Info(); {
+	case basic.Kind() == types.String:
+		expr = val
+		if !exact {
+			expr = "string(" + val + ")"
+		}
+	case basic.Kind() == types.Bool:
+		expr = "strconv.FormatBool(bool(" + val + "))"
+	case info&types.IsInteger != 0 && info&types.IsUnsigned != 0:
+		expr = "strconv.FormatUint(uint64(" + val + "), 10)"
+	case info&types.IsInteger != 0:
+		expr = "strconv.FormatInt(int64(" + val + "), 10)"
+	case basic.Kind() == types.Float32:
+		expr = "strconv.FormatFloat(float64(" + val + "), 'f', -1, 32)"
+	case basic.Kind() == types.Float64:
+		expr = "strconv.FormatFloat(float64(" + val + "), 'f', -1, 64)"
+	default:
+		return false
+	}
+
+	fmt.Fprintf(b, "values.Add(%s, %s)\n", name, expr)
+	return true
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateExample(t *testing.T) {
+	_, src, err := generate("internal/example", nil)
+	if err != nil {
+		fmt.Println("unexpected error:", err)
+		t.FailNow()
+	}
+
+	want, _ := os.ReadFile("internal/example/example_validate.go")
+	if !bytes.Equal(src, want) {
+		fmt.Println("internal/example/example_validate.go is out of date; run go generate ./...")
+		t.FailNow()
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	cases := map[string]string{
+		"type A struct {\n\tName string `validate:\"max_length=ten\"`\n}\n":                     "max_length",
+		"type A struct {\n\tName interface{} `validate:\"required\"`\n}\n":                      "interface",
+		"type A struct {\n\tName string `validate:\"required\"`\n}\n\nfunc (A) Validate() {}\n": "already has",
+	}
+
+	for src, want := range cases {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\n"+src), 0o644)
+
+		if _, _, err := generate(dir, nil); err == nil || !strings.Contains(err.Error(), want) {
+			fmt.Printf("expected an error containing %q, got %v\n", want, err)
+			t.FailNow()
+		}
+	}
+
+	if _, _, err := generate("internal/example", []string{"Untagged"}); err == nil {
+		fmt.Println("expected an error for a type without tags")
+		t.FailNow()
+	}
+}
+
+func TestGeneratedReportsMisconfiguredRules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a program")
+	}
+
+	// The package is written inside the module, so that it can import
+	// this version of validate.
+	dir, err := os.MkdirTemp("internal", "misconfigured")
+	if err != nil {
+		fmt.Println("unable to create the package:", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte(`package main
+
+import "fmt"
+
+type Parcel struct {
+	PostCode string `+"`validate:\"postal_code\"`"+`
+}
+
+func main() {
+	_, err := Parcel{PostCode: "LS1 1AA"}.Validate()
+	fmt.Println(err)
+}
+`), 0o644)
+
+	_, src, err := generate(dir, nil)
+	if err != nil {
+		fmt.Println("unexpected error:", err)
+		t.FailNow()
+	}
+	os.WriteFile(filepath.Join(dir, "main_validate.go"), src, 0o644)
+
+	// A rule that Compile cannot check is reported by Validate, and
+	// one that it can panics when the package is initialised.
+	out, _ := exec.Command("go", "run", "./"+filepath.ToSlash(dir)).CombinedOutput()
+	if !strings.Contains(string(out), "misconfigured postal_code rule for PostCode") {
+		fmt.Printf("expected the misconfigured rule to be reported, got %s\n", out)
+		t.FailNow()
+	}
+}
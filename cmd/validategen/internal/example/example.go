@@ -0,0 +1,48 @@
+// Package example has structs with `validate` tags, and the Validate
+// methods that validategen generates for them.
+package example
+
+import "time"
+
+//go:generate go run github.com/gostalt/validate/cmd/validategen
+
+type timestamps struct {
+	Created time.Time `json:"created"`
+	Updated *time.Time
+}
+
+// Level is formatted by its String method.
+type Level int
+
+func (l Level) String() string {
+	return [...]string{"low", "high"}[l%2]
+}
+
+type Address struct {
+	Line1   string `json:"line1" validate:"required"`
+	Country string `json:"country" validate:"country=alpha3"`
+}
+
+type User struct {
+	timestamps
+
+	Email    string            `json:"email" validate:"required,email,max_length=32"`
+	Name     *string           `json:"name" validate:"required,min_length=2"`
+	Age      int               `json:"age" validate:"integer"`
+	Score    float64           `json:"score" validate:"regex=^[0-9.]+$"`
+	Admin    bool              `json:"admin" validate:"boolean"`
+	Role     string            `json:"role" validate:"in=admin|editor"`
+	Tags     []string          `json:"tags" validate:"max_items=2,distinct"`
+	Joined   time.Time         `json:"joined" validate:"date_time"`
+	Level    Level             `json:"level" validate:"in=low"`
+	Address  Address           `json:"address"`
+	Previous []Address         `json:"previous" validate:"max_items=1"`
+	Labels   map[string]string `json:"labels"`
+	Secret   string            `json:"-" validate:"required"`
+	Friends  []*User           `json:"friends"`
+}
+
+// Untagged does not have a generated method.
+type Untagged struct {
+	Name string
+}
@@ -0,0 +1,129 @@
+package example
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gostalt/validate"
+)
+
+func TestGeneratedMatchesRuntime(t *testing.T) {
+	name, short := "Tom", "T"
+	now := time.Now()
+
+	users := []User{
+		{},
+		{
+			Email:  "me@tomm.us",
+			Name:   &name,
+			Age:    30,
+			Score:  9.5,
+			Role:   "admin",
+			Tags:   []string{"a", "b"},
+			Joined: now,
+			Address: Address{
+				Line1:   "1 High Street",
+				Country: "GBR",
+			},
+		},
+		{
+			Email:    "not an email address that is too long",
+			Name:     &short,
+			Score:    -1,
+			Role:     "owner",
+			Tags:     []string{"a", "a", "b"},
+			Level:    1,
+			Address:  Address{Country: "UK"},
+			Previous: []Address{{}, {}},
+			Labels:   map[string]string{"a": "b"},
+			Friends:  []*User{nil, {Email: "x"}},
+		},
+	}
+	users[0].Updated = &now
+
+	rules, err := validate.RulesFor(User{})
+	if err != nil {
+		fmt.Println("unexpected error:", err)
+		t.FailNow()
+	}
+
+	for i, u := range users {
+		want, wantErr := validate.Validate(context.Background(), u, rules...)
+		got, err := u.Validate()
+		if !reflect.DeepEqual(got, want) || err != wantErr {
+			fmt.Printf("user %d: generated %v, %v\nruntime   %v, %v\n", i, got, err, want, wantErr)
+			t.FailNow()
+		}
+	}
+
+	if msgs, err := users[1].Validate(); msgs != nil || err != nil {
+		fmt.Println("unexpected messages:", msgs, err)
+		t.FailNow()
+	}
+	if msgs, err := users[2].Validate(); len(msgs) == 0 || err != validate.ValidationFailed {
+		fmt.Println("unexpected messages:", msgs, err)
+		t.FailNow()
+	}
+}
+
+func TestFlattenedMatchesRuntime(t *testing.T) {
+	now := time.Now()
+	u := User{Tags: []string{"a"}, Labels: map[string]string{"k": "v"}, Previous: []Address{{Line1: "x"}}, Friends: []*User{{}}}
+	u.Updated = &now
+
+	want, _ := validate.Flatten(u)
+	values, raw, lists := make(map[string][]string), make(map[string]interface{}), make(map[string]bool)
+	validateFlattenUser(values, raw, lists, "", u)
+
+	if !reflect.DeepEqual(map[string][]string(want), values) {
+		fmt.Printf("generated %v\nruntime   %v\n", values, want)
+		t.FailNow()
+	}
+
+	for _, name := range []string{"tags", "labels", "previous", "previous.0", "address", "Updated", "friends.0"} {
+		if _, ok := raw[name]; !ok {
+			fmt.Println("expected a raw value for", name)
+			t.FailNow()
+		}
+	}
+
+	if !reflect.DeepEqual(lists, map[string]bool{"tags": true, "previous": true, "friends": true, "friends.0.tags": true, "friends.0.previous": true, "friends.0.friends": true}) {
+		fmt.Println("unexpected lists:", lists)
+		t.FailNow()
+	}
+}
+
+func benchmarkUser() User {
+	name := "Tom"
+	return User{
+		Email:   "me@tomm.us",
+		Name:    &name,
+		Age:     30,
+		Role:    "admin",
+		Tags:    []string{"a", "b"},
+		Joined:  time.Now(),
+		Address: Address{Line1: "1 High Street", Country: "GBR"},
+	}
+}
+
+func BenchmarkGenerated(b *testing.B) {
+	u := benchmarkUser()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		u.Validate()
+	}
+}
+
+func BenchmarkRuntime(b *testing.B) {
+	u := benchmarkUser()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rules, _ := validate.RulesFor(u)
+		validate.Validate(context.Background(), u, rules...)
+	}
+}
@@ -0,0 +1,128 @@
+// Code generated by validategen. DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/gostalt/validate"
+)
+
+var validateAddressRules = validate.MustCompile(validate.MustParseTags(
+	"line1", "required",
+	"country", "country=alpha3",
+)...)
+
+// Validate checks the Address against the rules in its validate tags.
+func (s Address) Validate() (validate.Message, error) {
+	values, raw, lists := make(url.Values), make(map[string]interface{}), make(map[string]bool)
+	validateFlattenAddress(values, raw, lists, "", s)
+	return validateAddressRules.ValidateData(context.Background(), validate.Flattened{Values: values, Raw: raw, Lists: lists})
+}
+
+var validateUserRules = validate.MustCompile(validate.MustParseTags(
+	"email", "required,email,max_length=32",
+	"name", "required,min_length=2",
+	"age", "integer",
+	"score", "regex=^[0-9.]+$",
+	"admin", "boolean",
+	"role", "in=admin|editor",
+	"tags", "max_items=2,distinct",
+	"joined", "date_time",
+	"level", "in=low",
+	"address.line1", "required",
+	"address.country", "country=alpha3",
+	"previous", "max_items=1",
+)...)
+
+// Validate checks the User against the rules in its validate tags.
+func (s User) Validate() (validate.Message, error) {
+	values, raw, lists := make(url.Values), make(map[string]interface{}), make(map[string]bool)
+	validateFlattenUser(values, raw, lists, "", s)
+	return validateUserRules.ValidateData(context.Background(), validate.Flattened{Values: values, Raw: raw, Lists: lists})
+}
+
+func validateFlattenAddress(values url.Values, raw map[string]interface{}, lists map[string]bool, prefix string, s Address) {
+	values.Add(prefix+"line1", s.Line1)
+	raw[prefix+"line1"] = s.Line1
+	values.Add(prefix+"country", s.Country)
+	raw[prefix+"country"] = s.Country
+}
+
+func validateFlattenUser(values url.Values, raw map[string]interface{}, lists map[string]bool, prefix string, s User) {
+	validateFlattenTimestamps(values, raw, lists, prefix, s.timestamps)
+	values.Add(prefix+"email", s.Email)
+	raw[prefix+"email"] = s.Email
+	if v2 := s.Name; v2 != nil {
+		values.Add(prefix+"name", *v2)
+		raw[prefix+"name"] = *v2
+	}
+	values.Add(prefix+"age", strconv.FormatInt(int64(s.Age), 10))
+	raw[prefix+"age"] = s.Age
+	values.Add(prefix+"score", strconv.FormatFloat(float64(s.Score), 'f', -1, 64))
+	raw[prefix+"score"] = s.Score
+	values.Add(prefix+"admin", strconv.FormatBool(bool(s.Admin)))
+	raw[prefix+"admin"] = s.Admin
+	values.Add(prefix+"role", s.Role)
+	raw[prefix+"role"] = s.Role
+	raw[prefix+"tags"] = s.Tags
+	v3 := prefix + "tags"
+	lists[v3] = true
+	if _, ok := values[v3]; !ok {
+		values[v3] = []string{}
+	}
+	for _, v5 := range s.Tags {
+		values.Add(v3, v5)
+	}
+	if text, err := s.Joined.MarshalText(); err == nil {
+		values.Add(prefix+"joined", string(text))
+	}
+	raw[prefix+"joined"] = s.Joined
+	values.Add(prefix+"level", s.Level.String())
+	raw[prefix+"level"] = s.Level
+	raw[prefix+"address"] = s.Address
+	validateFlattenAddress(values, raw, lists, prefix+"address.", s.Address)
+	raw[prefix+"previous"] = s.Previous
+	v6 := prefix + "previous"
+	lists[v6] = true
+	if _, ok := values[v6]; !ok {
+		values[v6] = []string{}
+	}
+	for v7, v8 := range s.Previous {
+		raw[v6+"."+strconv.Itoa(v7)] = v8
+		validateFlattenAddress(values, raw, lists, v6+"."+strconv.Itoa(v7)+".", v8)
+	}
+	raw[prefix+"labels"] = s.Labels
+	v9 := prefix + "labels"
+	for v10, v11 := range s.Labels {
+		values.Add(v9+"."+v10, v11)
+		raw[v9+"."+v10] = v11
+	}
+	raw[prefix+"friends"] = s.Friends
+	v12 := prefix + "friends"
+	lists[v12] = true
+	if _, ok := values[v12]; !ok {
+		values[v12] = []string{}
+	}
+	for v13, v14 := range s.Friends {
+		if v14 != nil {
+			raw[v12+"."+strconv.Itoa(v13)] = *v14
+			validateFlattenUser(values, raw, lists, v12+"."+strconv.Itoa(v13)+".", *v14)
+		}
+	}
+}
+
+func validateFlattenTimestamps(values url.Values, raw map[string]interface{}, lists map[string]bool, prefix string, s timestamps) {
+	if text, err := s.Created.MarshalText(); err == nil {
+		values.Add(prefix+"created", string(text))
+	}
+	raw[prefix+"created"] = s.Created
+	if v1 := s.Updated; v1 != nil {
+		if text, err := (*v1).MarshalText(); err == nil {
+			values.Add(prefix+"Updated", string(text))
+		}
+		raw[prefix+"Updated"] = *v1
+	}
+}
@@ -0,0 +1,77 @@
+// Command validategen generates a Validate method for each struct in
+// a package that has `validate` tags, so that the structs can be
+// validated without reflection. Add a directive to the package:
+//
+//	//go:generate go run github.com/gostalt/validate/cmd/validategen
+//
+// The generated method converts the struct's fields to values in the
+// same way as validate.Validate, and checks them with the rules that
+// validate.RulesFor reads from the tags, so the two give the same
+// messages and errors:
+//
+//	func (s User) Validate() (validate.Message, error)
+//
+// Like a Validator's Run method, it returns ValidationFailed with
+// the Message when the struct is invalid, and other errors when a
+// rule cannot be checked, such as a RuleError or a CheckError.
+//
+// The rules are compiled with validate.MustCompile when the package
+// is initialised, so a rule that is misconfigured, such as a regex
+// with a pattern that does not compile, panics once at startup, in
+// the same way as a tag that cannot be parsed, rather than on each
+// call to the method.
+//
+// By default every struct with tags is included, and the methods are
+// written to <package>_validate.go. Only one file should be generated
+// for each package.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of struct types; defaults to every struct with validate tags")
+	output := flag.String("output", "", "output file name; defaults to <package>_validate.go")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: validategen [-type T,U] [-output file] [directory]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	} else if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+
+	pkg, src, err := generate(dir, names)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "validategen:", err)
+		os.Exit(1)
+	}
+
+	file := *output
+	if file == "" {
+		file = pkg + "_validate.go"
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+
+	if err := os.WriteFile(file, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "validategen:", err)
+		os.Exit(1)
+	}
+}
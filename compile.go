@@ -0,0 +1,317 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// OptionsFunc returns an error if a Rule's Options cannot be used
+// by its CheckFunc, such as MaxLength without an int `length`.
+type OptionsFunc func(o Options) error
+
+var (
+	optionsMu    sync.RWMutex
+	optionsFuncs = make(map[string]OptionsFunc)
+)
+
+// RegisterOptions sets the OptionsFunc that Compile uses to check
+// the Options of rules with the given name. Like RegisterSchema, it
+// applies to rules whose Name is set, and to the built in rules by
+// their snake case names.
+func RegisterOptions(name string, fn OptionsFunc) {
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+
+	optionsFuncs[name] = fn
+}
+
+func init() {
+	intOption := func(key string) OptionsFunc {
+		return func(o Options) error {
+			if _, ok := o[key].(int); !ok {
+				return fmt.Errorf("%s must be an int", key)
+			}
+			return nil
+		}
+	}
+	listOption := func(key string) OptionsFunc {
+		return func(o Options) error {
+			if _, ok := o[key].([]string); !ok {
+				return fmt.Errorf("%s must be a []string", key)
+			}
+			return nil
+		}
+	}
+	sizeOf := func(o Options) error {
+		if _, ok := sizeOption(o, "size"); !ok {
+			return errors.New("size must be an int or int64")
+		}
+		return nil
+	}
+	pattern := func(o Options) error {
+		_, err := patternOption(o)
+		return err
+	}
+	values := func(o Options) error {
+		switch o["values"].(type) {
+		case []string, ValueSource:
+			return nil
+		}
+		return errors.New("values must be a []string or ValueSource")
+	}
+	// typed checks that each of the keys that is set holds a value
+	// of the Go type, as printed by %T, that it is mapped to. It is
+	// used for options that have defaults.
+	typed := func(types map[string]string) OptionsFunc {
+		return func(o Options) error {
+			for key, typ := range types {
+				if v, ok := o[key]; ok && fmt.Sprintf("%T", v) != typ {
+					return fmt.Errorf("%s must be of type %s", key, typ)
+				}
+			}
+			return nil
+		}
+	}
+	all := func(fns ...OptionsFunc) OptionsFunc {
+		return func(o Options) error {
+			for _, fn := range fns {
+				if err := fn(o); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	// Rules that parse dates accept the options used by Date, as well
+	// as the keys that they need.
+	dateTypes := typed(map[string]string{"format": "string", "formats": "[]string", "clock": "func() time.Time"})
+	dates := func(keys ...string) OptionsFunc {
+		return func(o Options) error {
+			if _, err := dateLocation(o); err != nil {
+				return err
+			}
+			if err := dateTypes(o); err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if _, ok := o[key]; !ok {
+					return fmt.Errorf("%s is required", key)
+				}
+				if _, err := resolveDate(o[key], o); err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+			}
+			return nil
+		}
+	}
+
+	country := func(o Options) error {
+		_, err := countryFormat(o)
+		return err
+	}
+	mode := func(o Options) error {
+		_, err := emailMode(o)
+		return err
+	}
+	email := all(mode, typed(map[string]string{
+		"require_tld":       "bool",
+		"reject_ip_literal": "bool",
+		"reject_quoted":     "bool",
+		"reject_plus":       "bool",
+	}))
+	phone := func(o Options) error {
+		_, _, err := phoneOptions(o)
+		return err
+	}
+	postalCode := func(o Options) error {
+		if code, ok := o["country"].(string); ok {
+			if _, ok := lookupCountry(code); !ok {
+				return fmt.Errorf("unknown country %q", code)
+			}
+			return nil
+		}
+		if _, ok := o["field"].(string); !ok {
+			return errors.New("country or field must be a string")
+		}
+		return nil
+	}
+	format := func(o Options) error {
+		if _, ok := o["format"].(string); !ok {
+			return errors.New("format must be a string")
+		}
+		return nil
+	}
+	ratio := func(o Options) error {
+		if _, ok := parseRatio(o["ratio"]); !ok {
+			return errors.New("ratio must be a width:height string or a float64")
+		}
+		return nil
+	}
+	maxSize := func(o Options) error {
+		if _, set := o["max_size"]; set {
+			if _, ok := sizeOption(o, "max_size"); !ok {
+				return errors.New("max_size must be an int or int64")
+			}
+		}
+		return nil
+	}
+
+	builtin := map[string]OptionsFunc{
+		"max_length":       intOption("length"),
+		"min_length":       intOption("length"),
+		"regex":            pattern,
+		"not_regex":        pattern,
+		"in":               values,
+		"not_in":           values,
+		"in_fold":          values,
+		"not_in_fold":      values,
+		"min_items":        intOption("count"),
+		"max_items":        intOption("count"),
+		"max_files":        intOption("count"),
+		"max_file_size":    sizeOf,
+		"min_file_size":    sizeOf,
+		"file_extension":   listOption("extensions"),
+		"mime_type":        listOption("types"),
+		"min_age":          all(intOption("age"), dates()),
+		"max_age":          all(intOption("age"), dates()),
+		"date":             dates(),
+		"date_format":      format,
+		"before":           dates("date"),
+		"after":            dates("date"),
+		"between":          dates("from", "to"),
+		"weekday":          all(dates(), typed(map[string]string{"days": "[]time.Weekday"})),
+		"business_day":     all(dates(), typed(map[string]string{"holidays": "[]string"})),
+		"date_only":        dateTimeOptions,
+		"time_only":        dateTimeOptions,
+		"date_time":        dateTimeOptions,
+		"duration":         modeOption,
+		"email":            email,
+		"disposable_email": mode,
+		"mx_email":         typed(map[string]string{"timeout": "int"}),
+		"telnet_email": typed(map[string]string{
+			"timeout":          "int",
+			"helo":             "string",
+			"from":             "string",
+			"port":             "string",
+			"allow_unknown":    "bool",
+			"reject_catch_all": "bool",
+		}),
+		"country":     country,
+		"postal_code": postalCode,
+		"phone":       phone,
+		"distinct":    typed(map[string]string{"fold": "bool"}),
+		"image":       typed(map[string]string{"formats": "[]string"}),
+		"image_dimensions": typed(map[string]string{
+			"min_width":  "int",
+			"max_width":  "int",
+			"min_height": "int",
+			"max_height": "int",
+		}),
+		"aspect_ratio": all(ratio, typed(map[string]string{"tolerance": "float64"})),
+		"zip":          all(maxSize, typed(map[string]string{"max_entries": "int", "allow_nested": "bool"})),
+		"pdf":          typed(map[string]string{"min_version": "string", "max_version": "string"}),
+	}
+	for name, fn := range builtin {
+		optionsFuncs[name] = fn
+	}
+}
+
+// checkOptions returns an error if the Rule cannot be run.
+func checkOptions(rule Rule) error {
+	if rule.Check == nil {
+		return errors.New("rule has no check")
+	}
+	if !rule.Source.valid() {
+		return fmt.Errorf("unknown source %q", rule.Source)
+	}
+
+	optionsMu.RLock()
+	fn, ok := optionsFuncs[ruleName(rule)]
+	optionsMu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	return fn(rule.Options)
+}
+
+// Compiled is a set of rules that has been checked once by Compile,
+// and can then validate any number of requests, including
+// concurrently.
+type Compiled struct {
+	rules []Rule
+	// fieldFuncs holds the FieldFunc of each built in rule, and nil
+	// for the others, so that they are not looked up on each call.
+	fieldFuncs []FieldFunc
+}
+
+// Compile checks the rules and returns a Compiled set of them that
+// can be reused for every request to a route, rather than building
+// the rules again for each one. Each rule must have a Check and a
+// valid Source, and the Options of built in rules are checked, so
+// that MaxLength without a `length` or Regex with a pattern that
+// does not compile is reported here instead of on every request.
+// Other CheckFuncs can have their Options checked with
+// RegisterOptions. The error is a *RuleError, which matches
+// ErrMisconfiguredRule.
+//
+// The rules and their Options must not be modified after they are
+// compiled.
+func Compile(rules ...Rule) (*Compiled, error) {
+	if len(rules) == 0 {
+		return nil, EmptyRuleset
+	}
+
+	c := &Compiled{rules: append([]Rule(nil), rules...), fieldFuncs: make([]FieldFunc, len(rules))}
+	for i, rule := range c.rules {
+		if err := checkOptions(rule); err != nil {
+			return nil, ruleError(rule, err)
+		}
+		c.fieldFuncs[i], _ = rule.Check.FieldFunc()
+	}
+
+	return c, nil
+}
+
+// MustCompile is like Compile, but panics if the rules cannot be
+// compiled. It simplifies initialising package level variables.
+func MustCompile(rules ...Rule) *Compiled {
+	c, err := Compile(rules...)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// Rules returns a copy of the compiled rules.
+func (c *Compiled) Rules() []Rule {
+	return append([]Rule(nil), c.rules...)
+}
+
+// Validator creates a Validator for the request with the compiled
+// rules, so that its settings, such as Strict, can be changed before
+// it is run.
+func (c *Compiled) Validator(r *http.Request) *Validator {
+	// The capacity is limited so that Add copies the rules rather
+	// than appending to the shared slice.
+	return Make(r, c.rules[:len(c.rules):len(c.rules)]...)
+}
+
+// Validate determines if the compiled rules are satisfied by the
+// request. It is safe to call from multiple goroutines.
+func (c *Compiled) Validate(r *http.Request) (Message, error) {
+	v := c.Validator(r)
+	v.fieldFuncs = c.fieldFuncs
+	return v.Run()
+}
+
+// ValidateData determines if the compiled rules are satisfied by
+// data, in the same way as the Validator's Validate method. It is
+// safe to call from multiple goroutines.
+func (c *Compiled) ValidateData(ctx context.Context, data interface{}) (Message, error) {
+	return (&Validator{Rules: c.rules, fieldFuncs: c.fieldFuncs}).Validate(ctx, data)
+}
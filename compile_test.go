@@ -0,0 +1,337 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func signupRules() []Rule {
+	return []Rule{
+		{Param: "email", Check: Required},
+		{Param: "email", Check: Email},
+		{Param: "name", Check: MaxLength, Options: Options{"length": 10}, Filters: []Filter{Trim}},
+		{Param: "code", Check: Regex, Options: Options{"pattern": `^[A-Z]{3}$`}},
+		{Param: "plan", Check: In, Options: Options{"values": []string{"free", "pro"}}},
+		{Param: "page", Source: Query, Check: Integer},
+	}
+}
+
+func signupRequest(form url.Values) *http.Request {
+	r := httptest.NewRequest("POST", "/signup?page=2", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestCompile(t *testing.T) {
+	s, err := Compile(signupRules()...)
+	if err != nil {
+		fmt.Println(err)
+		t.FailNow()
+	}
+
+	msgs, err := s.Validate(signupRequest(url.Values{
+		"email": {"jo@example.com"},
+		"name":  {"  Jo  "},
+		"code":  {"ABC"},
+		"plan":  {"pro"},
+	}))
+	if err != nil {
+		fmt.Println(msgs)
+		t.FailNow()
+	}
+
+	msgs, err = s.Validate(signupRequest(url.Values{
+		"name": {"Johnathan Smith"},
+		"code": {"abc"},
+		"plan": {"gold"},
+	}))
+	if err != ValidationFailed || len(msgs["email"]) != 2 || len(msgs["name"]) != 1 || len(msgs["code"]) != 1 || len(msgs["plan"]) != 1 {
+		fmt.Println("expected email, name, code and plan to fail, got", msgs)
+		t.FailNow()
+	}
+
+	msgs, err = MustCompile(signupRules()[:5]...).ValidateData(context.Background(), map[string]string{"email": "jo@example.com", "code": "ABC", "plan": "gold"})
+	if err != ValidationFailed || len(msgs) != 1 || len(msgs["plan"]) != 1 {
+		fmt.Println("expected plan to fail, got", msgs)
+		t.FailNow()
+	}
+}
+
+func TestCompileConcurrently(t *testing.T) {
+	s := MustCompile(signupRules()...)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			plan := "free"
+			if i%2 == 1 {
+				plan = "gold"
+			}
+			msgs, _ := s.Validate(signupRequest(url.Values{
+				"email": {fmt.Sprintf("user%d@example.com", i)},
+				"code":  {"ABC"},
+				"plan":  {plan},
+			}))
+			if (len(msgs) > 0) != (i%2 == 1) {
+				t.Errorf("request %d got %v", i, msgs)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestCompileChecksOptions(t *testing.T) {
+	tests := map[string]Rule{
+		"length must be an int":                  {Param: "name", Check: MaxLength},
+		"count must be an int":                   {Param: "tags", Check: MinItems, Options: Options{"count": "2"}},
+		"error parsing regexp":                   {Param: "code", Check: Regex, Options: Options{"pattern": `[A-Z`}},
+		"values must be a []string":              {Param: "plan", Check: In, Options: Options{"values": "free"}},
+		"size must be an int":                    {Param: "avatar", Check: MaxFileSize},
+		"extensions must be a []string":          {Param: "avatar", Check: FileExtension},
+		"from is required":                       {Param: "date", Check: Between, Options: Options{"to": "today"}},
+		"date: ":                                 {Param: "date", Check: Before, Options: Options{"date": "soon"}},
+		"unknown time zone":                      {Param: "date", Check: Weekday, Options: Options{"timezone": "Mars/Olympus_Mons"}},
+		"rule has no check":                      {Param: "name"},
+		`unknown source "fragment"`:              {Param: "name", Source: "fragment", Check: Required},
+		"misconfigured min_length rule for name": {Param: "name", Check: MinLength, Options: Options{"length": 2.5}},
+	}
+
+	for want, rule := range tests {
+		_, err := Compile(rule)
+		if !errors.Is(err, ErrMisconfiguredRule) || !strings.Contains(err.Error(), want) {
+			fmt.Printf("expected an error containing %q, got %v\n", want, err)
+			t.FailNow()
+		}
+	}
+
+	if _, err := Compile(); err != EmptyRuleset {
+		fmt.Println("expected `EmptyRuleset` error, got", err)
+		t.FailNow()
+	}
+}
+
+// TestCompileChecksBuiltinOptions lists every built in rule, so that
+// one that reads its Options cannot be added without an OptionsFunc.
+// Good Options must compile, and Bad ones must not. Rules that read
+// no Options have neither.
+func TestCompileChecksBuiltinOptions(t *testing.T) {
+	png := Options{"formats": []string{"png"}}
+	tests := map[string]struct{ Good, Bad Options }{
+		"required":         {},
+		"empty":            {},
+		"alpha":            {},
+		"alphanumeric":     {},
+		"integer":          {},
+		"boolean":          {},
+		"list":             {},
+		"required_file":    {},
+		"language":         {},
+		"timezone":         {},
+		"rfc3339":          {},
+		"rfc1123":          {},
+		"rfc822":           {},
+		"unix_date":        {},
+		"max_length":       {Options{"length": 5}, Options{"length": "5"}},
+		"min_length":       {Options{"length": 5}, Options{}},
+		"regex":            {Options{"pattern": "^a$"}, Options{"pattern": "("}},
+		"not_regex":        {Options{"pattern": "^a$"}, Options{}},
+		"email":            {Options{"mode": EmailHTML5, "reject_plus": true}, Options{"reject_plus": "yes"}},
+		"mx_email":         {Options{"timeout": 2}, Options{"timeout": 2.5}},
+		"telnet_email":     {Options{"helo": "tomm.us", "port": "2525"}, Options{"port": 2525}},
+		"disposable_email": {Options{"mode": EmailIntl}, Options{"mode": "loose"}},
+		"date_format":      {Options{"format": "2006-01-02"}, Options{}},
+		"date":             {Options{"formats": []string{"02/01/2006"}}, Options{"formats": "02/01/2006"}},
+		"date_only":        {Options{"mode": "lenient"}, Options{"timezone": "Mars/Olympus_Mons"}},
+		"time_only":        {Options{"timezone": "Europe/London"}, Options{"mode": "loose"}},
+		"date_time":        {Options{"timezone": "Europe/London"}, Options{"timezone": 1}},
+		"duration":         {Options{"mode": "strict"}, Options{"mode": 1}},
+		"before":           {Options{"date": "today"}, Options{"date": "soon"}},
+		"after":            {Options{"date": "-1y"}, Options{}},
+		"between":          {Options{"from": "-1y", "to": "today"}, Options{"from": "-1y"}},
+		"min_age":          {Options{"age": 18}, Options{"age": 18, "clock": "now"}},
+		"max_age":          {Options{"age": 65}, Options{"age": "65"}},
+		"weekday":          {Options{"days": []time.Weekday{time.Saturday}}, Options{"days": []string{"Saturday"}}},
+		"business_day":     {Options{"holidays": []string{"2024-12-25"}}, Options{"holidays": "2024-12-25"}},
+		"country":          {Options{"format": "alpha3"}, Options{"format": "name"}},
+		"postal_code":      {Options{"country": "GB"}, Options{"country": "XX"}},
+		"phone":            {Options{"region": "GB", "types": []string{PhoneMobile}}, Options{"region": "XX"}},
+		"in":               {Options{"values": []string{"a"}}, Options{}},
+		"not_in":           {Options{"values": []string{"a"}}, Options{"values": "a"}},
+		"in_fold":          {Options{"values": []string{"a"}}, Options{}},
+		"not_in_fold":      {Options{"values": []string{"a"}}, Options{}},
+		"min_items":        {Options{"count": 1}, Options{}},
+		"max_items":        {Options{"count": 1}, Options{"count": 1.5}},
+		"distinct":         {Options{"fold": true}, Options{"fold": "true"}},
+		"max_file_size":    {Options{"size": int64(1 << 20)}, Options{"size": "1MB"}},
+		"min_file_size":    {Options{"size": 1}, Options{}},
+		"max_files":        {Options{"count": 1}, Options{}},
+		"file_extension":   {Options{"extensions": []string{".png"}}, Options{}},
+		"mime_type":        {Options{"types": []string{"image/png"}}, Options{}},
+		"image":            {png, Options{"formats": "png"}},
+		"image_dimensions": {Options{"min_width": 100}, Options{"max_height": "100"}},
+		"aspect_ratio":     {Options{"ratio": "16:9", "tolerance": 0.1}, Options{"ratio": "16:9", "tolerance": 1}},
+		"zip":              {Options{"max_size": int64(1 << 20), "max_entries": 10}, Options{"max_size": "1MB"}},
+		"pdf":              {Options{"min_version": "1.4"}, Options{"min_version": 1.4}},
+	}
+
+	for _, name := range builtinNames {
+		test, ok := tests[name]
+		if !ok {
+			fmt.Println("add", name, "to the table, with Options that Compile must reject if it reads any")
+			t.FailNow()
+		}
+
+		check, _ := checkNamed(name)
+		_, hasFunc := optionsFuncs[name]
+		if test.Bad == nil {
+			if hasFunc {
+				fmt.Println("expected Options that Compile rejects for", name)
+				t.FailNow()
+			}
+			continue
+		}
+
+		if _, err := Compile(Rule{Param: "a", Check: check, Options: test.Good}); err != nil {
+			fmt.Println(name, "unexpected error:", err)
+			t.FailNow()
+		}
+		_, err := Compile(Rule{Param: "a", Check: check, Options: test.Bad})
+		if !errors.Is(err, ErrMisconfiguredRule) || !strings.Contains(err.Error(), "misconfigured "+name+" rule") {
+			fmt.Println(name, "expected a misconfigured rule, got", err)
+			t.FailNow()
+		}
+	}
+
+	if len(tests) != len(builtinNames) {
+		fmt.Println("the table has rules that are not built in")
+		t.FailNow()
+	}
+}
+
+func TestRegisterOptions(t *testing.T) {
+	var check CheckFunc = func(r *http.Request, param string, o Options) error {
+		return nil
+	}
+	RegisterOptions("currency", func(o Options) error {
+		if _, ok := o["currency"].(string); !ok {
+			return errors.New("currency must be a string")
+		}
+		return nil
+	})
+
+	if _, err := Compile(Rule{Param: "price", Check: check, Name: "currency"}); err == nil || !strings.Contains(err.Error(), "misconfigured currency rule") {
+		fmt.Println("expected the registered OptionsFunc to fail, got", err)
+		t.FailNow()
+	}
+	if _, err := Compile(Rule{Param: "price", Check: check, Name: "currency", Options: Options{"currency": "GBP"}}); err != nil {
+		fmt.Println(err)
+		t.FailNow()
+	}
+	if _, err := Compile(Rule{Param: "price", Check: check}); err != nil {
+		fmt.Println("expected an unnamed rule not to be checked, got", err)
+		t.FailNow()
+	}
+}
+
+func TestCompiledValidatorAdd(t *testing.T) {
+	s := MustCompile(Rule{Param: "email", Check: Required})
+
+	v := s.Validator(signupRequest(url.Values{}))
+	v.Add(Rule{Param: "name", Check: Required})
+	if msgs, _ := v.Run(); len(msgs) != 2 {
+		fmt.Println("expected email and name to be required, got", msgs)
+		t.FailNow()
+	}
+
+	if rules := s.Rules(); len(rules) != 1 {
+		fmt.Println("expected Add not to change the compiled rules, got", len(rules))
+		t.FailNow()
+	}
+}
+
+func TestCompileResolvesFieldFuncs(t *testing.T) {
+	even := CheckFunc(func(r *http.Request, param string, _ Options) error {
+		if len(r.Form.Get(param))%2 != 0 {
+			return fmt.Errorf("%s must have an even length", param)
+		}
+		return nil
+	})
+	s := MustCompile(Rule{Param: "email", Check: Required}, Rule{Param: "code", Check: even})
+
+	if s.fieldFuncs[0] == nil || s.fieldFuncs[1] != nil {
+		fmt.Println("expected only the built in rule to have a FieldFunc")
+		t.FailNow()
+	}
+
+	msgs, _ := s.ValidateData(context.Background(), map[string]interface{}{"code": "abc"})
+	if len(msgs) != 2 || len(msgs["email"]) == 0 || len(msgs["code"]) == 0 {
+		fmt.Println("unexpected messages:", msgs)
+		t.FailNow()
+	}
+	msgs, _ = s.Validate(signupRequest(url.Values{"code": {"abc"}}))
+	if len(msgs) != 2 {
+		fmt.Println("unexpected messages:", msgs)
+		t.FailNow()
+	}
+}
+
+// The benchmarks validate the same parsed request, so they report
+// the allocations made by the Validator for each request.
+
+func BenchmarkCheck(b *testing.B) {
+	r := signupRequest(url.Values{"email": {"jo@example.com"}, "name": {"Jo"}, "code": {"ABC"}, "plan": {"pro"}})
+	r.ParseForm()
+
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		Check(r, signupRules()...)
+	}
+}
+
+func BenchmarkCompiled(b *testing.B) {
+	r := signupRequest(url.Values{"email": {"jo@example.com"}, "name": {"Jo"}, "code": {"ABC"}, "plan": {"pro"}})
+	r.ParseForm()
+	s := MustCompile(signupRules()...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		s.Validate(r)
+	}
+}
+
+func BenchmarkCompiledFailure(b *testing.B) {
+	r := signupRequest(url.Values{"name": {"Johnathan Smith"}, "code": {"abc"}, "plan": {"gold"}})
+	r.ParseForm()
+	s := MustCompile(signupRules()...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		s.Validate(r)
+	}
+}
+
+func BenchmarkCompiledParallel(b *testing.B) {
+	s := MustCompile(signupRules()...)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		r := signupRequest(url.Values{"email": {"jo@example.com"}, "name": {"Jo"}, "code": {"ABC"}, "plan": {"pro"}})
+		r.ParseForm()
+		for pb.Next() {
+			s.Validate(r)
+		}
+	})
+}
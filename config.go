@@ -0,0 +1,340 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigError is returned when a rule set file cannot be loaded. It
+// holds the line that the problem was found on, or 0 if the line is
+// not known.
+type ConfigError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	switch {
+	case e.File == "" && e.Line == 0:
+		return e.Err.Error()
+	case e.File == "":
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	case e.Line == 0:
+		return fmt.Sprintf("%s: %v", e.File, e.Err)
+	}
+
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+func configErrorf(line int, format string, args ...interface{}) error {
+	return &ConfigError{Line: line, Err: fmt.Errorf(format, args...)}
+}
+
+// configNode is a value read from a config file, with the line that
+// it starts on. Value is nil, a bool, int, float64 or string, a
+// []*configNode or a map[string]*configNode.
+type configNode struct {
+	Line  int
+	Value interface{}
+}
+
+// parseConfig parses a JSON, YAML or TOML document.
+func parseConfig(data []byte, format string) (*configNode, error) {
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "json":
+		return parseJSONConfig(data)
+	case "yaml", "yml":
+		return parseYAMLConfig(data)
+	case "toml":
+		return parseTOMLConfig(data)
+	}
+
+	return nil, fmt.Errorf("unknown config format %q", format)
+}
+
+// lineAt returns the line of the byte at offset.
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	return 1 + bytes.Count(data[:offset], []byte("\n"))
+}
+
+// jsonConfig parses JSON into configNodes.
+type jsonConfig struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+func parseJSONConfig(data []byte) (*configNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	p := &jsonConfig{data: data, dec: dec}
+
+	node, err := p.value()
+	if err == nil {
+		if _, extra := dec.Token(); extra != io.EOF {
+			return nil, configErrorf(p.line(), "unexpected data after the document")
+		}
+	}
+
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		return nil, configErrorf(lineAt(data, syntax.Offset), "%s", syntax.Error())
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return nil, configErrorf(lineAt(data, int64(len(data))), "unexpected end of the document")
+	}
+
+	return node, err
+}
+
+// line returns the line of the next token.
+func (p *jsonConfig) line() int {
+	offset := p.dec.InputOffset()
+	for offset < int64(len(p.data)) && strings.IndexByte(" \t\r\n,:", p.data[offset]) >= 0 {
+		offset++
+	}
+
+	return lineAt(p.data, offset)
+}
+
+func (p *jsonConfig) value() (*configNode, error) {
+	line := p.line()
+	tok, err := p.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '{':
+			m := make(map[string]*configNode)
+			for p.dec.More() {
+				keyLine := p.line()
+				key, err := p.dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				name := key.(string)
+				if _, ok := m[name]; ok {
+					return nil, configErrorf(keyLine, "duplicate key %q", name)
+				}
+
+				if m[name], err = p.value(); err != nil {
+					return nil, err
+				}
+			}
+			_, err = p.dec.Token()
+			return &configNode{line, m}, err
+		case '[':
+			var a []*configNode
+			for p.dec.More() {
+				item, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, item)
+			}
+			_, err = p.dec.Token()
+			return &configNode{line, a}, err
+		}
+	case json.Number:
+		if n, err := strconv.Atoi(tok.String()); err == nil {
+			return &configNode{line, n}, nil
+		}
+		f, _ := tok.Float64()
+		return &configNode{line, f}, nil
+	}
+
+	return &configNode{line, tok}, nil
+}
+
+// parseYAMLConfig parses a YAML document into configNodes. Scalars
+// follow the YAML 1.2 core schema, so `yes` is a string, and
+// timestamps are kept as they were written.
+func parseYAMLConfig(data []byte) (*configNode, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, yamlConfigError(data, err)
+	}
+	if len(doc.Content) == 0 {
+		return &configNode{1, nil}, nil
+	}
+
+	return yamlConfigNode(doc.Content[0])
+}
+
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// yamlConfigError converts an error from the YAML parser, which has
+// its line in the message, into a ConfigError.
+func yamlConfigError(data []byte, err error) error {
+	msg := err.Error()
+	if m := yamlErrorLine.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return configErrorf(line, "%s", strings.TrimPrefix(msg, m[0]))
+	}
+
+	return configErrorf(lineAt(data, int64(len(data))), "%s", strings.TrimPrefix(msg, "yaml: "))
+}
+
+func yamlConfigNode(node *yaml.Node) (*configNode, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		alias, err := yamlConfigNode(node.Alias)
+		if err != nil {
+			return nil, err
+		}
+		return &configNode{node.Line, alias.Value}, nil
+	case yaml.MappingNode:
+		m := make(map[string]*configNode, len(node.Content)/2)
+		var merged []*configNode
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child, err := yamlConfigNode(value)
+			if err != nil {
+				return nil, err
+			}
+
+			if key.ShortTag() == "!!merge" {
+				merged = append(merged, child)
+				continue
+			}
+			if key.Kind != yaml.ScalarNode {
+				return nil, configErrorf(key.Line, "keys must be strings")
+			}
+			if _, ok := m[key.Value]; ok {
+				return nil, configErrorf(key.Line, "duplicate key %q", key.Value)
+			}
+			m[key.Value] = child
+		}
+
+		// Keys from `<<` merge keys do not replace the mapping's own
+		// keys, or those from earlier merges.
+		for len(merged) > 0 {
+			from := merged[0]
+			merged = merged[1:]
+			switch v := from.Value.(type) {
+			case map[string]*configNode:
+				for k, child := range v {
+					if _, ok := m[k]; !ok {
+						m[k] = child
+					}
+				}
+			case []*configNode:
+				merged = append(v, merged...)
+			default:
+				return nil, configErrorf(from.Line, "only mappings can be merged")
+			}
+		}
+		return &configNode{node.Line, m}, nil
+	case yaml.SequenceNode:
+		a := make([]*configNode, len(node.Content))
+		for i, item := range node.Content {
+			child, err := yamlConfigNode(item)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = child
+		}
+		return &configNode{node.Line, a}, nil
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return &configNode{node.Line, nil}, nil
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return nil, configErrorf(node.Line, "invalid boolean %q", node.Value)
+		}
+		return &configNode{node.Line, b}, nil
+	case "!!int":
+		var n int
+		if err := node.Decode(&n); err != nil {
+			return nil, configErrorf(node.Line, "invalid integer %q", node.Value)
+		}
+		return &configNode{node.Line, n}, nil
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return nil, configErrorf(node.Line, "invalid number %q", node.Value)
+		}
+		return &configNode{node.Line, f}, nil
+	}
+
+	return &configNode{node.Line, node.Value}, nil
+}
+
+// parseTOMLConfig parses a TOML document into configNodes. Dates and
+// times are kept as strings in the form they were written in. The
+// TOML parser does not report where each value is, so the nodes have
+// no line, and only syntax errors are reported with one.
+func parseTOMLConfig(data []byte) (*configNode, error) {
+	var root map[string]interface{}
+	if _, err := toml.Decode(string(data), &root); err != nil {
+		var pe toml.ParseError
+		if errors.As(err, &pe) {
+			return nil, configErrorf(pe.Position.Line, "%s", pe.Message)
+		}
+		return nil, &ConfigError{Err: err}
+	}
+
+	return tomlConfigNode(root), nil
+}
+
+func tomlConfigNode(value interface{}) *configNode {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]*configNode, len(v))
+		for key, child := range v {
+			m[key] = tomlConfigNode(child)
+		}
+		return &configNode{0, m}
+	case []map[string]interface{}:
+		a := make([]*configNode, len(v))
+		for i, child := range v {
+			a[i] = tomlConfigNode(child)
+		}
+		return &configNode{0, a}
+	case []interface{}:
+		a := make([]*configNode, len(v))
+		for i, child := range v {
+			a[i] = tomlConfigNode(child)
+		}
+		return &configNode{0, a}
+	case int64:
+		return &configNode{0, int(v)}
+	case time.Time:
+		// Local dates and times are given zones with these names.
+		switch v.Location().String() {
+		case "date-local":
+			return &configNode{0, v.Format(time.DateOnly)}
+		case "time-local":
+			return &configNode{0, v.Format("15:04:05.999999999")}
+		case "datetime-local":
+			return &configNode{0, v.Format("2006-01-02T15:04:05.999999999")}
+		}
+		return &configNode{0, v.Format(time.RFC3339Nano)}
+	}
+
+	return &configNode{0, value}
+}
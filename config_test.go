@@ -0,0 +1,198 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	want := map[string]interface{}{
+		"name":  "O'Brien # not a comment",
+		"count": 3,
+		"ratio": 1.5,
+		"on":    true,
+		"tags":  []string{"a", "b, c"},
+		"table": map[string]interface{}{"key": "value", "empty": nil},
+		"list":  []interface{}{map[string]interface{}{"a": 1, "b": 2}, map[string]interface{}{"a": 3}},
+	}
+
+	docs := map[string]string{
+		"json": `{
+			"name": "O'Brien # not a comment",
+			"count": 3,
+			"ratio": 1.5,
+			"on": true,
+			"tags": ["a", "b, c"],
+			"table": {"key": "value", "empty": null},
+			"list": [{"a": 1, "b": 2}, {"a": 3}]
+		}`,
+		"yaml": `
+# A comment
+name: "O'Brien # not a comment"
+count: 3 # trailing
+ratio: 1.5
+on: true
+tags: [a, "b, c"]
+table:
+  key: value
+  empty:
+list:
+  - a: 1
+    b: 2
+  - {a: 3}
+`,
+		"toml": `
+name = "O'Brien # not a comment"
+count = 3 # trailing
+ratio = 1.5
+on = true
+tags = [
+  "a",
+  'b, c', # comment
+]
+
+[table]
+key = "value"
+
+[[list]]
+a = 1
+b = 2
+
+[[list]]
+a = 3
+`,
+	}
+
+	for format, doc := range docs {
+		node, err := parseConfig([]byte(doc), format)
+		if err != nil {
+			fmt.Println(format, "unexpected error:", err)
+			t.FailNow()
+		}
+
+		got := configValue(node).(map[string]interface{})
+		if format == "toml" {
+			// TOML has no null.
+			got["table"].(map[string]interface{})["empty"] = nil
+		}
+		if !reflect.DeepEqual(got, want) {
+			fmt.Printf("%s: got %#v\n", format, got)
+			t.FailNow()
+		}
+	}
+}
+
+func TestConfigScalars(t *testing.T) {
+	yaml := map[string]interface{}{
+		"1_000":      1000,
+		"0o17":       15,
+		"0x1F":       31,
+		"-2.5":       -2.5,
+		"1e3":        1000.0,
+		"True":       true,
+		"yes":        "yes",
+		"~":          nil,
+		".inf":       math.Inf(1),
+		"2001-12-14": "2001-12-14",
+	}
+	for raw, want := range yaml {
+		node, err := parseConfig([]byte("v: "+raw), "yaml")
+		if err != nil {
+			fmt.Printf("yaml %q: unexpected error: %v\n", raw, err)
+			t.FailNow()
+		}
+		if got := configValue(node).(map[string]interface{})["v"]; got != want {
+			fmt.Printf("yaml %q: expected %#v, got %#v\n", raw, want, got)
+			t.FailNow()
+		}
+	}
+
+	toml := map[string]interface{}{
+		"1_000":                1000,
+		"0o17":                 15,
+		"0x1F":                 31,
+		"0b101":                5,
+		"-2.5":                 -2.5,
+		"1e3":                  1000.0,
+		"-inf":                 math.Inf(-1),
+		"true":                 true,
+		"1979-05-27":           "1979-05-27",
+		"07:32:00":             "07:32:00",
+		"1979-05-27T07:32:00Z": "1979-05-27T07:32:00Z",
+		"True":                 nil,
+		"017":                  nil,
+	}
+	for raw, want := range toml {
+		node, err := parseConfig([]byte("v = "+raw), "toml")
+		if want == nil {
+			if err == nil {
+				fmt.Printf("toml %q: expected an error\n", raw)
+				t.FailNow()
+			}
+			continue
+		}
+		if err != nil {
+			fmt.Printf("toml %q: unexpected error: %v\n", raw, err)
+			t.FailNow()
+		}
+		if got := configValue(node).(map[string]interface{})["v"]; got != want {
+			fmt.Printf("toml %q: expected %#v, got %#v\n", raw, want, got)
+			t.FailNow()
+		}
+	}
+}
+
+func TestParseConfigMergeKeys(t *testing.T) {
+	doc := `
+base: &base
+  length: 10
+  trim: true
+name:
+  <<: *base
+  length: 20
+`
+	node, err := parseConfig([]byte(doc), "yaml")
+	if err != nil {
+		fmt.Println("unexpected error:", err)
+		t.FailNow()
+	}
+
+	got := configValue(node).(map[string]interface{})["name"]
+	want := map[string]interface{}{"length": 20, "trim": true}
+	if !reflect.DeepEqual(got, want) {
+		fmt.Printf("expected %#v, got %#v\n", want, got)
+		t.FailNow()
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	cases := []struct {
+		format string
+		doc    string
+		line   int
+	}{
+		{"json", "{\n\"a\": 1,\n\"b\": ]\n}", 3},
+		{"json", "{\n\"a\": 1,\n\"a\": 2\n}", 3},
+		{"json", "{\n\"a\": [1, 2\n", 3},
+		{"yaml", "a:\n  b: 1\n    c: 2\n", 3},
+		{"yaml", "a: 1\nb: c: d\n", 2},
+		{"yaml", "a: 1\na: 2\n", 2},
+		{"yaml", "a: &x 1\nb:\n  <<: *x\n", 3},
+		{"toml", "a = 1\n\nb = \n", 3},
+		{"toml", "a = 1\na = 2\n", 2},
+		{"toml", "[t]\nx = 1\n[t]\n", 3},
+		{"toml", "a = 1\nb = 1979-05-32\n", 2},
+	}
+
+	for _, c := range cases {
+		_, err := parseConfig([]byte(c.doc), c.format)
+		var ce *ConfigError
+		if !errors.As(err, &ce) || ce.Line != c.line {
+			fmt.Printf("%s %q: expected an error on line %d, got %v\n", c.format, c.doc, c.line, err)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Now returns the current time for rules that compare dates
+// against the present, such as Before, After and MinAge, and for
+// expiring cached DNS results. It can be replaced to make those
+// deterministic in tests. A single Rule can instead pass a `clock`
+// func in its Options.
+var Now = time.Now
+
+// relativeDate matches a single step of a relative date expression,
+// such as the `-18y` in `-18y` or the `+1m` in `-1y+1m`.
+var relativeDate = regexp.MustCompile(`^([+-])(\d+)([ymwdhs])`)
+
+// parseDate parses value using the date formats accepted by Date,
+// along with any custom `formats` in the Options map. If a single
+// `format` is passed, only that format is used. Values without a
+// UTC offset are parsed in the Options' `timezone`.
+func parseDate(value string, o Options) (time.Time, error) {
+	loc, err := dateLocation(o)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	formats := dateFormats
+	if format, ok := o["format"].(string); ok {
+		formats = []string{format}
+	} else if custom, ok := o["formats"].([]string); ok {
+		formats = append(append([]string{}, dateFormats...), custom...)
+	}
+
+	for _, format := range formats {
+		if t, err := time.ParseInLocation(format, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	if _, ok := o["format"]; !ok {
+		if t, _, err := ParseDateTime(value, Options{"timezone": loc}); err == nil {
+			return t, nil
+		}
+		if t, _, err := ParseDateOnly(value, Options{"timezone": loc}); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q does not satisfy any date format", value)
+}
+
+// dateLocation returns the location given by the `timezone` key in
+// the Options map, which can be a *time.Location or an IANA zone
+// name. It defaults to UTC.
+func dateLocation(o Options) (*time.Location, error) {
+	switch tz := o["timezone"].(type) {
+	case *time.Location:
+		return tz, nil
+	case string:
+		return time.LoadLocation(tz)
+	case nil:
+		return time.UTC, nil
+	default:
+		return nil, fmt.Errorf("unable to use %v as a timezone", tz)
+	}
+}
+
+// dateNow returns the current time in the Options' timezone, using
+// the `clock` func in the Options map if there is one.
+func dateNow(o Options) (time.Time, error) {
+	loc, err := dateLocation(o)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	clock, ok := o["clock"].(func() time.Time)
+	if !ok {
+		clock = Now
+	}
+
+	return clock().In(loc), nil
+}
+
+// resolveDate turns a date bound from the Options map into a time.
+// The bound can be a time.Time, an absolute date in any format that
+// parseDate accepts, or a relative expression:
+// `now`, `today`, `tomorrow`, `yesterday`, or one or more signed
+// offsets such as `-18y`, `+30d` or `-1y+6m`. Offsets use y, m, w,
+// d, h and s for years, months, weeks, days, hours and seconds.
+func resolveDate(bound interface{}, o Options) (time.Time, error) {
+	if t, ok := bound.(time.Time); ok {
+		return t, nil
+	}
+
+	expr, ok := bound.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("unable to use %v as a date", bound)
+	}
+
+	now, err := dateNow(o)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch expr {
+	case "now":
+		return now, nil
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if relativeDate.MatchString(expr) {
+		t := now
+		for rest := expr; rest != ""; {
+			m := relativeDate.FindStringSubmatch(rest)
+			if m == nil {
+				return time.Time{}, fmt.Errorf("unable to parse relative date %q", expr)
+			}
+			rest = rest[len(m[0]):]
+
+			n, _ := strconv.Atoi(m[2])
+			if m[1] == "-" {
+				n = -n
+			}
+
+			switch m[3] {
+			case "y":
+				t = t.AddDate(n, 0, 0)
+			case "m":
+				t = t.AddDate(0, n, 0)
+			case "w":
+				t = t.AddDate(0, 0, 7*n)
+			case "d":
+				t = t.AddDate(0, 0, n)
+			case "h":
+				t = t.Add(time.Duration(n) * time.Hour)
+			case "s":
+				t = t.Add(time.Duration(n) * time.Second)
+			}
+		}
+
+		return t, nil
+	}
+
+	return parseDate(expr, Options{"timezone": now.Location()})
+}
+
+// formatBound formats a resolved date bound for an error message.
+func formatBound(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02")
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// paramDate parses the parameter with parseDate, and records the
+// date to be returned by the Validator's Validated method.
+func paramDate(ctx context.Context, f Field) (time.Time, error) {
+	t, err := parseDate(f.String(), f.Options)
+	if err == nil {
+		setValue(ctx, f.Name, t)
+	}
+
+	return t, err
+}
+
+// dateAndBound parses the parameter and resolves the bound stored
+// under key in the Options map.
+func dateAndBound(ctx context.Context, f Field, key string) (time.Time, time.Time, error) {
+	bound, ok := f.Options[key]
+	if !ok {
+		return time.Time{}, time.Time{}, misconfigured(f.Name, "%s is required", key)
+	}
+
+	b, err := resolveDate(bound, f.Options)
+	if err != nil {
+		return time.Time{}, time.Time{}, misconfigured(f.Name, "%s: %w", key, err)
+	}
+
+	t, err := paramDate(ctx, f)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s must be a valid date", f.Name)
+	}
+
+	return t, b, nil
+}
+
+// Before returns an error if the parameter is not a date before the
+// `date` key in the Options map. See resolveDate for the forms the
+// bound can take, such as `today` or `-18y`.
+//
+// All of the date comparison rules accept the `formats`, `format`,
+// `timezone` and `clock` keys in the Options map.
+var Before CheckFunc = func(r *http.Request, param string, o Options) error {
+	return beforeField(r.Context(), requestField(r, param, o))
+}
+
+func beforeField(ctx context.Context, f Field) error {
+	t, bound, err := dateAndBound(ctx, f, "date")
+	if err != nil {
+		return err
+	}
+
+	if !t.Before(bound) {
+		return fmt.Errorf("%s must be a date before %s", f.Name, formatBound(bound))
+	}
+
+	return nil
+}
+
+// After returns an error if the parameter is not a date after the
+// `date` key in the Options map.
+var After CheckFunc = func(r *http.Request, param string, o Options) error {
+	return afterField(r.Context(), requestField(r, param, o))
+}
+
+func afterField(ctx context.Context, f Field) error {
+	t, bound, err := dateAndBound(ctx, f, "date")
+	if err != nil {
+		return err
+	}
+
+	if !t.After(bound) {
+		return fmt.Errorf("%s must be a date after %s", f.Name, formatBound(bound))
+	}
+
+	return nil
+}
+
+// Between returns an error if the parameter is not a date between
+// the `from` and `to` keys in the Options map, inclusive.
+var Between CheckFunc = func(r *http.Request, param string, o Options) error {
+	return betweenField(r.Context(), requestField(r, param, o))
+}
+
+func betweenField(ctx context.Context, f Field) error {
+	t, from, err := dateAndBound(ctx, f, "from")
+	if err != nil {
+		return err
+	}
+
+	_, to, err := dateAndBound(ctx, f, "to")
+	if err != nil {
+		return err
+	}
+
+	if t.Before(from) || t.After(to) {
+		return fmt.Errorf("%s must be a date between %s and %s", f.Name, formatBound(from), formatBound(to))
+	}
+
+	return nil
+}
+
+// age returns the number of whole years between a date of birth
+// and now.
+func age(dob time.Time, now time.Time) int {
+	dob = dob.In(now.Location())
+
+	years := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		years--
+	}
+
+	return years
+}
+
+// MinAge returns an error if the parameter is a date of birth for
+// someone younger than the `age` key in the Options map.
+var MinAge CheckFunc = func(r *http.Request, param string, o Options) error {
+	return minAgeField(r.Context(), requestField(r, param, o))
+}
+
+func minAgeField(ctx context.Context, f Field) error {
+	years, dob, now, err := ageOptions(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	if age(dob, now) < years {
+		return fmt.Errorf("%s must be at least %d years ago", f.Name, years)
+	}
+
+	return nil
+}
+
+// MaxAge returns an error if the parameter is a date of birth for
+// someone older than the `age` key in the Options map.
+var MaxAge CheckFunc = func(r *http.Request, param string, o Options) error {
+	return maxAgeField(r.Context(), requestField(r, param, o))
+}
+
+func maxAgeField(ctx context.Context, f Field) error {
+	years, dob, now, err := ageOptions(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	if age(dob, now) > years {
+		return fmt.Errorf("%s must be no more than %d years ago", f.Name, years)
+	}
+
+	return nil
+}
+
+func ageOptions(ctx context.Context, f Field) (int, time.Time, time.Time, error) {
+	years, ok := f.Options["age"].(int)
+	if !ok {
+		return 0, time.Time{}, time.Time{}, misconfigured(f.Name, "age must be an int")
+	}
+
+	now, err := dateNow(f.Options)
+	if err != nil {
+		return 0, time.Time{}, time.Time{}, misconfigured(f.Name, "%w", err)
+	}
+
+	dob, err := paramDate(ctx, f)
+	if err != nil {
+		return 0, time.Time{}, time.Time{}, fmt.Errorf("%s must be a valid date", f.Name)
+	}
+
+	return years, dob, now, nil
+}
+
+// Weekday returns an error if the parameter is a date that falls on
+// a weekend. Other days can be allowed by passing a slice of
+// time.Weekday with a `days` key in the Options map. The day is
+// determined in the Options' `timezone`.
+var Weekday CheckFunc = func(r *http.Request, param string, o Options) error {
+	return weekdayField(r.Context(), requestField(r, param, o))
+}
+
+func weekdayField(ctx context.Context, f Field) error {
+	days, ok := f.Options["days"].([]time.Weekday)
+	if !ok {
+		days = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	}
+
+	loc, err := dateLocation(f.Options)
+	if err != nil {
+		return misconfigured(f.Name, "%w", err)
+	}
+
+	t, err := paramDate(ctx, f)
+	if err != nil {
+		return fmt.Errorf("%s must be a valid date", f.Name)
+	}
+
+	day := t.In(loc).Weekday()
+	for _, d := range days {
+		if d == day {
+			return nil
+		}
+	}
+
+	names := make([]string, len(days))
+	for i, d := range days {
+		names[i] = d.String()
+	}
+
+	return fmt.Errorf("%s must fall on a %s", f.Name, strings.Join(names, ", "))
+}
+
+// BusinessDay returns an error if the parameter is a date that falls
+// on a weekend or on one of the `holidays` in the Options map. The
+// holidays are a slice of dates in `2006-01-02` format.
+var BusinessDay CheckFunc = func(r *http.Request, param string, o Options) error {
+	return businessDayField(r.Context(), requestField(r, param, o))
+}
+
+func businessDayField(ctx context.Context, f Field) error {
+	loc, err := dateLocation(f.Options)
+	if err != nil {
+		return misconfigured(f.Name, "%w", err)
+	}
+
+	t, err := paramDate(ctx, f)
+	if err != nil {
+		return fmt.Errorf("%s must be a valid date", f.Name)
+	}
+	t = t.In(loc)
+
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return fmt.Errorf("%s must be a business day", f.Name)
+	}
+
+	holidays, _ := f.Options["holidays"].([]string)
+	for _, h := range holidays {
+		if t.Format("2006-01-02") == h {
+			return fmt.Errorf("%s must be a business day", f.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDateRules(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+
+	clock := func() time.Time {
+		return time.Date(2020, time.June, 15, 12, 0, 0, 0, time.UTC)
+	}
+
+	rules := []struct {
+		Check   CheckFunc
+		Passes  []string
+		Fails   []string
+		Options Options
+	}{
+		{
+			Before,
+			[]string{"2019-12-31T23:59:59Z"},
+			[]string{"2020-01-01T00:00:00Z", "2021-01-01T00:00:00Z", "not a date"},
+			Options{"date": "2020-01-01"},
+		},
+		{
+			Before,
+			[]string{"2002-06-15T00:00:00Z"},
+			[]string{"2002-06-16T00:00:00Z"},
+			Options{"date": "-18y", "clock": clock},
+		},
+		{
+			After,
+			[]string{"2020-07-15T12:00:01Z", "2021-01-01T00:00:00Z"},
+			[]string{"2020-07-15T00:00:00Z", "2019-01-01T00:00:00Z"},
+			Options{"date": "+30d", "clock": clock},
+		},
+		{
+			After,
+			[]string{"2020-06-15T12:00:01Z"},
+			[]string{"2020-06-15T12:00:00Z"},
+			Options{"date": "now", "clock": clock},
+		},
+		{
+			Between,
+			[]string{"2020-06-15T00:00:00Z", "2020-06-22T00:00:00Z", "2020-06-18T10:00:00Z"},
+			[]string{"2020-06-14T23:59:59Z", "2020-06-22T00:00:01Z"},
+			Options{"from": "today", "to": "+1w-12h", "clock": clock},
+		},
+		{
+			// London is an hour ahead of UTC in June.
+			Between,
+			[]string{"2020-06-15 00:30"},
+			[]string{"2020-06-15 01:30"},
+			Options{
+				"from":     time.Date(2020, time.June, 14, 23, 0, 0, 0, time.UTC),
+				"to":       time.Date(2020, time.June, 14, 23, 59, 0, 0, time.UTC),
+				"format":   "2006-01-02 15:04",
+				"timezone": "Europe/London",
+			},
+		},
+		{
+			MinAge,
+			[]string{"2002-06-15", "1970-01-01"},
+			[]string{"2002-06-16", "2019-01-01", "sometime"},
+			Options{"age": 18, "formats": []string{"2006-01-02"}, "clock": clock},
+		},
+		{
+			MaxAge,
+			[]string{"1955-06-16", "2010-01-01"},
+			[]string{"1955-06-15", "1900-01-01"},
+			Options{"age": 64, "formats": []string{"2006-01-02"}, "clock": clock},
+		},
+		{
+			Weekday,
+			[]string{"2020-06-15T00:00:00Z", "2020-06-19T00:00:00Z"},
+			[]string{"2020-06-20T00:00:00Z", "2020-06-21T00:00:00Z"},
+			nil,
+		},
+		{
+			Weekday,
+			[]string{"2020-06-20T00:00:00Z"},
+			[]string{"2020-06-15T00:00:00Z"},
+			Options{"days": []time.Weekday{time.Saturday}},
+		},
+		{
+			// Midnight on Saturday in Sydney is still Friday in UTC.
+			Weekday,
+			[]string{"2020-06-19T23:00:00+10:00"},
+			[]string{"2020-06-19T15:00:00Z"},
+			Options{"timezone": "Australia/Sydney"},
+		},
+		{
+			BusinessDay,
+			[]string{"2020-06-16T09:00:00Z"},
+			[]string{"2020-06-15T09:00:00Z", "2020-06-20T09:00:00Z"},
+			Options{"holidays": []string{"2020-06-15"}},
+		},
+	}
+
+	for _, rule := range rules {
+		for _, value := range rule.Passes {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: rule.Check, Options: rule.Options})
+			if len(msgs) > 0 {
+				fmt.Println("Got an error, expected none:", msgs["parameter"])
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+
+		for _, value := range rule.Fails {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: rule.Check, Options: rule.Options})
+			if len(msgs) == 0 {
+				fmt.Println("Expected an error, didn't get one")
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestDateRulesUsePackageClock(t *testing.T) {
+	defer func(now func() time.Time) { Now = now }(Now)
+	Now = func() time.Time {
+		return time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+	r.Form.Set("date", "1999-12-31T00:00:00Z")
+
+	if msgs, _ := Check(r, Rule{Param: "date", Check: Before, Options: Options{"date": "now"}}); len(msgs) > 0 {
+		fmt.Println("expected the replaced clock to be used, got", msgs)
+		t.FailNow()
+	}
+}
+
+func TestWeekdayRulesNeedATimezone(t *testing.T) {
+	r, _ := http.NewRequest("GET", "localhost?date=2020-06-15", nil)
+	r.ParseForm()
+
+	for _, check := range []CheckFunc{Weekday, BusinessDay} {
+		err := check(r, "date", Options{"timezone": "Mars/Olympus_Mons"})
+		if !errors.Is(err, ErrMisconfiguredRule) {
+			fmt.Println("expected a configuration error for the timezone, got", err)
+			t.FailNow()
+		}
+	}
+}
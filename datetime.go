@@ -0,0 +1,511 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The ISO 8601 representations reported by ParseDateOnly,
+// ParseTimeOnly and ParseDateTime.
+const (
+	ISOCalendarDate = "ISO 8601 calendar date"
+	ISOWeekDate     = "ISO 8601 week date"
+	ISOOrdinalDate  = "ISO 8601 ordinal date"
+	ISOTime         = "ISO 8601 time"
+	ISODateTime     = "ISO 8601 date and time"
+)
+
+// lenientDateFormats are the layouts that DateOnly accepts in
+// addition to ISO 8601 in lenient mode.
+var lenientDateFormats = []string{
+	"2006/01/02",
+	"02/01/2006",
+	"02 Jan 2006",
+	"2 January 2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"Mon, 02 Jan 2006",
+	"Monday, 02 January 2006",
+}
+
+// lenientTimeFormats are the layouts that TimeOnly accepts in
+// addition to ISO 8601 in lenient mode.
+var lenientTimeFormats = []string{
+	time.Kitchen,
+	"3:04 PM",
+	"3:04pm",
+	"3:04 pm",
+	"15.04",
+}
+
+// lenientDateTimeFormats are the layouts that DateTime accepts in
+// addition to ISO 8601 in lenient mode.
+var lenientDateTimeFormats = []string{
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+	time.RFC822,
+	time.RFC822Z,
+	time.RFC850,
+	time.RFC1123,
+	time.RFC1123Z,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05Z07:00",
+}
+
+// DateOnly returns an error if the parameter is not a date without
+// a time, such as `2006-01-02`. ISO 8601 calendar (`2006-01-02` or
+// `20060102`), week (`2006-W01-1`) and ordinal (`2006-002`) dates
+// are accepted. Passing `"mode": "lenient"` in the Options map also
+// accepts common layouts such as `02 Jan 2006`. An unknown `mode` or
+// `timezone` makes this and the other ISO 8601 rules misconfigured.
+var DateOnly CheckFunc = func(r *http.Request, param string, o Options) error {
+	return dateOnlyField(r.Context(), requestField(r, param, o))
+}
+
+func dateOnlyField(ctx context.Context, f Field) error {
+	if err := dateTimeOptions(f.Options); err != nil {
+		return misconfigured(f.Name, "%w", err)
+	}
+
+	t, _, err := ParseDateOnly(f.String(), f.Options)
+	if err != nil {
+		return fmt.Errorf("%s must be a valid date", f.Name)
+	}
+
+	setValue(ctx, f.Name, t)
+	return nil
+}
+
+// TimeOnly returns an error if the parameter is not a time of day,
+// such as `15:04`, `15:04:05.000` or `T150405Z`. Passing
+// `"mode": "lenient"` in the Options map also accepts layouts
+// such as `3:04PM`.
+var TimeOnly CheckFunc = func(r *http.Request, param string, o Options) error {
+	return timeOnlyField(r.Context(), requestField(r, param, o))
+}
+
+func timeOnlyField(ctx context.Context, f Field) error {
+	if err := dateTimeOptions(f.Options); err != nil {
+		return misconfigured(f.Name, "%w", err)
+	}
+
+	t, _, err := ParseTimeOnly(f.String(), f.Options)
+	if err != nil {
+		return fmt.Errorf("%s must be a valid time", f.Name)
+	}
+
+	setValue(ctx, f.Name, t)
+	return nil
+}
+
+// DateTime returns an error if the parameter is not an ISO 8601
+// date and time, such as `2006-01-02T15:04:05Z`. Any of the date
+// representations accepted by DateOnly can be used. Passing
+// `"mode": "lenient"` in the Options map also accepts a space
+// separator, lower case designators and Go's built-in layouts.
+var DateTime CheckFunc = func(r *http.Request, param string, o Options) error {
+	return dateTimeField(r.Context(), requestField(r, param, o))
+}
+
+func dateTimeField(ctx context.Context, f Field) error {
+	if err := dateTimeOptions(f.Options); err != nil {
+		return misconfigured(f.Name, "%w", err)
+	}
+
+	t, _, err := ParseDateTime(f.String(), f.Options)
+	if err != nil {
+		return fmt.Errorf("%s must be a valid date and time", f.Name)
+	}
+
+	setValue(ctx, f.Name, t)
+	return nil
+}
+
+// Duration returns an error if the parameter is not an ISO 8601
+// duration, such as `P1DT2H` or `PT0.5S`. In strict mode weeks
+// cannot be combined with other units; `"mode": "lenient"` in the
+// Options map allows durations such as `P1W2D`.
+var Duration CheckFunc = func(r *http.Request, param string, o Options) error {
+	return durationField(r.Context(), requestField(r, param, o))
+}
+
+func durationField(ctx context.Context, f Field) error {
+	if err := modeOption(f.Options); err != nil {
+		return misconfigured(f.Name, "%w", err)
+	}
+
+	d, err := ParseISODuration(f.String(), lenient(f.Options))
+	if err != nil {
+		return fmt.Errorf("%s must be a valid duration", f.Name)
+	}
+
+	setValue(ctx, f.Name, d)
+	return nil
+}
+
+// dateTimeOptions returns an error if the Options' `timezone` cannot
+// be loaded or its `mode` is unknown.
+func dateTimeOptions(o Options) error {
+	if _, err := dateLocation(o); err != nil {
+		return err
+	}
+
+	return modeOption(o)
+}
+
+// modeOption returns an error if the Options' `mode` is not "strict"
+// or "lenient".
+func modeOption(o Options) error {
+	switch mode := o["mode"].(type) {
+	case nil:
+		return nil
+	case string:
+		if mode == "" || mode == "strict" || mode == "lenient" {
+			return nil
+		}
+		return fmt.Errorf("unknown mode %q", mode)
+	}
+
+	return errors.New("mode must be a string")
+}
+
+func lenient(o Options) bool {
+	mode, _ := o["mode"].(string)
+	return mode == "lenient"
+}
+
+// ParseDateOnly parses a date in the way that DateOnly validates
+// it and returns the name of the format that matched: one of the
+// ISO* constants, or a Go layout in lenient mode.
+func ParseDateOnly(value string, o Options) (time.Time, string, error) {
+	loc, err := dateLocation(o)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	if t, format, _, ok := parseISODate(value, loc); ok {
+		return t, format, nil
+	}
+
+	if lenient(o) {
+		for _, layout := range lenientDateFormats {
+			if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+				return t, layout, nil
+			}
+		}
+	}
+
+	return time.Time{}, "", fmt.Errorf("%q is not a valid date", value)
+}
+
+// ParseTimeOnly parses a time of day in the way that TimeOnly
+// validates it and returns the name of the format that matched.
+// The returned time is on 1 January of year 0.
+func ParseTimeOnly(value string, o Options) (time.Time, string, error) {
+	loc, err := dateLocation(o)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	base := time.Date(0, time.January, 1, 0, 0, 0, 0, loc)
+	if t, _, ok := parseISOTime(strings.TrimPrefix(value, "T"), base); ok {
+		return t, ISOTime, nil
+	}
+
+	if lenient(o) {
+		for _, layout := range lenientTimeFormats {
+			if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+				return t, layout, nil
+			}
+		}
+	}
+
+	return time.Time{}, "", fmt.Errorf("%q is not a valid time", value)
+}
+
+// ParseDateTime parses a date and time in the way that DateTime
+// validates it and returns the name of the format that matched.
+// Values without a UTC offset are parsed in the Options' timezone.
+func ParseDateTime(value string, o Options) (time.Time, string, error) {
+	loc, err := dateLocation(o)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	iso := value
+	if lenient(o) {
+		iso = strings.ToUpper(iso)
+		if i := strings.IndexByte(iso, ' '); i > 0 && strings.Count(iso, " ") == 1 {
+			iso = iso[:i] + "T" + iso[i+1:]
+		}
+	}
+
+	if i := strings.IndexByte(iso, 'T'); i > 0 {
+		if d, _, basic, ok := parseISODate(iso[:i], loc); ok {
+			t, timeBasic, ok := parseISOTime(iso[i+1:], d)
+			if ok && (basic == timeBasic || lenient(o)) {
+				return t, ISODateTime, nil
+			}
+		}
+	}
+
+	if lenient(o) {
+		for _, layout := range lenientDateTimeFormats {
+			if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+				return t, layout, nil
+			}
+		}
+	}
+
+	return time.Time{}, "", fmt.Errorf("%q is not a valid date and time", value)
+}
+
+// parseISODate parses an ISO 8601 calendar, week or ordinal date in
+// either the basic or extended format. It reports which
+// representation matched and whether it used the basic format.
+func parseISODate(s string, loc *time.Location) (time.Time, string, bool, bool) {
+	if len(s) < 7 || !isDigits(s[:4]) {
+		return time.Time{}, "", false, false
+	}
+
+	year, _ := strconv.Atoi(s[:4])
+	rest := s[4:]
+
+	basic := rest[0] != '-'
+	if !basic {
+		rest = rest[1:]
+	}
+
+	// Week dates: 2006-W01-1 or 2006W011.
+	if rest[0] == 'W' {
+		rest = rest[1:]
+		if !basic {
+			rest = strings.Replace(rest, "-", "", 1)
+			if len(s) != 10 || s[8] != '-' {
+				return time.Time{}, "", false, false
+			}
+		}
+		if len(rest) != 3 || !isDigits(rest) {
+			return time.Time{}, "", false, false
+		}
+
+		week, _ := strconv.Atoi(rest[:2])
+		day := int(rest[2] - '0')
+		if week < 1 || week > isoWeeksInYear(year) || day < 1 || day > 7 {
+			return time.Time{}, "", false, false
+		}
+
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+		monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+		return monday.AddDate(0, 0, (week-1)*7+day-1), ISOWeekDate, basic, true
+	}
+
+	if !isDigits(strings.Replace(rest, "-", "", 1)) {
+		return time.Time{}, "", false, false
+	}
+
+	// Ordinal dates: 2006-002 or 2006002.
+	if len(rest) == 3 {
+		day, _ := strconv.Atoi(rest)
+		t := time.Date(year, time.January, day, 0, 0, 0, 0, loc)
+		if day < 1 || t.Year() != year {
+			return time.Time{}, "", false, false
+		}
+		return t, ISOOrdinalDate, basic, true
+	}
+
+	// Calendar dates: 2006-01-02 or 20060102.
+	if (basic && len(rest) != 4) || (!basic && (len(rest) != 5 || rest[2] != '-')) {
+		return time.Time{}, "", false, false
+	}
+
+	month, _ := strconv.Atoi(rest[:2])
+	day, _ := strconv.Atoi(rest[len(rest)-2:])
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+	if month < 1 || month > 12 || t.Day() != day {
+		return time.Time{}, "", false, false
+	}
+
+	return t, ISOCalendarDate, basic, true
+}
+
+// isoWeeksInYear returns 53 for years that start on a Thursday, or
+// leap years that start on a Wednesday, and 52 otherwise.
+func isoWeeksInYear(year int) int {
+	jan1 := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	dec31 := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).Weekday()
+	if jan1 == time.Thursday || dec31 == time.Thursday {
+		return 53
+	}
+
+	return 52
+}
+
+// parseISOTime parses an ISO 8601 time of day, with an optional
+// fraction of a second and UTC offset, onto the date of base. It
+// reports whether the basic format was used.
+func parseISOTime(s string, base time.Time) (time.Time, bool, bool) {
+	loc := base.Location()
+
+	// Split off the UTC offset.
+	if strings.HasSuffix(s, "Z") {
+		s = s[:len(s)-1]
+		loc = time.UTC
+	} else if i := strings.IndexAny(s, "+-"); i >= 0 {
+		offset, ok := parseISOOffset(s[i:])
+		if !ok {
+			return time.Time{}, false, false
+		}
+		s = s[:i]
+		loc = offset
+	}
+
+	// Split off the fraction of a second.
+	nsec := 0
+	if i := strings.IndexAny(s, ".,"); i >= 0 {
+		frac := s[i+1:]
+		if frac == "" || len(frac) > 9 || !isDigits(frac) {
+			return time.Time{}, false, false
+		}
+		nsec, _ = strconv.Atoi(frac + strings.Repeat("0", 9-len(frac)))
+		s = s[:i]
+	}
+
+	basic := !strings.Contains(s, ":")
+	if !basic {
+		s = strings.Replace(s, ":", "", 2)
+		if strings.Contains(s, ":") {
+			return time.Time{}, false, false
+		}
+	}
+
+	if (len(s) != 4 && len(s) != 6) || !isDigits(s) {
+		return time.Time{}, false, false
+	}
+
+	hour, _ := strconv.Atoi(s[0:2])
+	min, _ := strconv.Atoi(s[2:4])
+	sec := 0
+	if len(s) == 6 {
+		sec, _ = strconv.Atoi(s[4:6])
+	} else if nsec > 0 {
+		return time.Time{}, false, false
+	}
+
+	// 24:00 is the end of the day, and nothing after it.
+	if hour == 24 && (min > 0 || sec > 0 || nsec > 0) {
+		return time.Time{}, false, false
+	}
+
+	if hour > 24 || min > 59 || sec > 59 {
+		return time.Time{}, false, false
+	}
+
+	t := time.Date(base.Year(), base.Month(), base.Day(), hour, min, sec, nsec, loc)
+	return t, basic, true
+}
+
+// parseISOOffset parses a UTC offset such as `+01:00`, `+0100`
+// or `+01`.
+func parseISOOffset(s string) (*time.Location, bool) {
+	sign := 1
+	if s[0] == '-' {
+		sign = -1
+	}
+
+	s = strings.Replace(s[1:], ":", "", 1)
+	if (len(s) != 2 && len(s) != 4) || !isDigits(s) {
+		return nil, false
+	}
+
+	hours, _ := strconv.Atoi(s[:2])
+	mins := 0
+	if len(s) == 4 {
+		mins, _ = strconv.Atoi(s[2:])
+	}
+
+	if hours > 23 || mins > 59 {
+		return nil, false
+	}
+
+	return time.FixedZone("", sign*(hours*3600+mins*60)), true
+}
+
+// ISODuration is a parsed ISO 8601 duration. Years, months, weeks
+// and days are kept separate because their length depends on the
+// date they are added to.
+type ISODuration struct {
+	Years, Months, Weeks, Days float64
+	Hours, Minutes, Seconds    float64
+}
+
+// ParseISODuration parses an ISO 8601 duration such as `P1DT2H`,
+// `P3W` or `PT1.5S`. Only the smallest unit may have a fraction.
+// Weeks can only be combined with other units when lenient is true.
+func ParseISODuration(s string, lenient bool) (ISODuration, error) {
+	var d ISODuration
+
+	if len(s) < 3 || s[0] != 'P' || strings.HasSuffix(s, "T") {
+		return d, fmt.Errorf("%q is not a valid duration", s)
+	}
+
+	units := "YMWD"
+	fields := []*float64{&d.Years, &d.Months, &d.Weeks, &d.Days}
+	timeFields := []*float64{&d.Hours, &d.Minutes, &d.Seconds}
+
+	inTime := false
+	count, weeks, fraction := 0, false, false
+	for rest := s[1:]; rest != ""; {
+		if rest[0] == 'T' {
+			if inTime {
+				return d, fmt.Errorf("%q is not a valid duration", s)
+			}
+			inTime = true
+			units = "HMS"
+			fields = timeFields
+			rest = rest[1:]
+			continue
+		}
+
+		i := 0
+		for i < len(rest) && (rest[i] >= '0' && rest[i] <= '9' || rest[i] == '.' || rest[i] == ',') {
+			i++
+		}
+		if i == 0 || i == len(rest) || fraction {
+			return d, fmt.Errorf("%q is not a valid duration", s)
+		}
+
+		number := strings.Replace(rest[:i], ",", ".", 1)
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil || strings.HasPrefix(number, ".") || strings.HasSuffix(number, ".") {
+			return d, fmt.Errorf("%q is not a valid duration", s)
+		}
+		fraction = strings.Contains(number, ".")
+
+		// Units must appear in order, and at most once.
+		u := strings.IndexByte(units, rest[i])
+		if u < 0 {
+			return d, fmt.Errorf("%q is not a valid duration", s)
+		}
+		*fields[u] = n
+		units = units[u+1:]
+		fields = fields[u+1:]
+
+		weeks = weeks || (!inTime && rest[i] == 'W')
+		count++
+		rest = rest[i+1:]
+	}
+
+	if count == 0 || (weeks && count > 1 && !lenient) {
+		return d, fmt.Errorf("%q is not a valid duration", s)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,143 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDateTimeRules(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+
+	rules := []struct {
+		Check   CheckFunc
+		Passes  []string
+		Fails   []string
+		Options Options
+	}{
+		{
+			DateOnly,
+			[]string{"2006-01-02", "20060102", "2020-02-29", "2020-W53-7", "2020W537", "2020-366", "2019001"},
+			[]string{"", "2006-1-2", "2019-02-29", "2006-13-01", "2019-W53-1", "2019-366", "2006-0102", "02 Jan 2006", "2006-01-02T15:04:05Z"},
+			nil,
+		},
+		{
+			DateOnly,
+			[]string{"2006-01-02", "02 Jan 2006", "2006/01/02", "January 2, 2006"},
+			[]string{"Jan 32, 2006", "tomorrow"},
+			Options{"mode": "lenient"},
+		},
+		{
+			TimeOnly,
+			[]string{"15:04", "15:04:05", "15:04:05.999", "150405", "T15:04:05Z", "15:04+01:00", "24:00", "23:59:59,5"},
+			[]string{"", "3:04PM", "25:00", "15:60", "24:00:01", "15:04:05.", "15", "15:04:05+1"},
+			nil,
+		},
+		{
+			TimeOnly,
+			[]string{"15:04", "3:04PM", "3:04 pm"},
+			[]string{"13:04PM"},
+			Options{"mode": "lenient"},
+		},
+		{
+			DateTime,
+			[]string{"2006-01-02T15:04:05Z", "2006-01-02T15:04:05.123+07:00", "20060102T150405Z", "2006-W01-1T15:04", "2006-002T15:04Z"},
+			[]string{"", "2006-01-02", "2006-01-02 15:04:05", "2006-01-02T150405Z", "2006-01-02T25:00Z", "Mon Jan  2 15:04:05 2006"},
+			nil,
+		},
+		{
+			DateTime,
+			[]string{"2006-01-02 15:04:05", "2006-01-02t15:04:05z", "2006-01-02T150405Z", "Mon Jan  2 15:04:05 2006"},
+			[]string{"2006-01-02", "15:04:05"},
+			Options{"mode": "lenient"},
+		},
+		{
+			Duration,
+			[]string{"P1DT2H", "P1Y2M3DT4H5M6S", "PT0.5S", "P3W", "PT36H", "P0D", "P1,5Y"},
+			[]string{"", "P", "PT", "1D", "P1H", "P2D1Y", "P1.5Y2M", "P1W2D", "PT1D", "P1DT"},
+			nil,
+		},
+		{
+			Duration,
+			[]string{"P1W2D"},
+			[]string{"P1D1W"},
+			Options{"mode": "lenient"},
+		},
+		{
+			// Date also accepts ISO 8601 dates now.
+			Date,
+			[]string{"2006-01-02", "2006-W01-1T15:04"},
+			[]string{"2006/01/02"},
+			nil,
+		},
+	}
+
+	for _, rule := range rules {
+		for _, value := range rule.Passes {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: rule.Check, Options: rule.Options})
+			if len(msgs) > 0 {
+				fmt.Println("Got an error, expected none:", msgs["parameter"])
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+
+		for _, value := range rule.Fails {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: rule.Check, Options: rule.Options})
+			if len(msgs) == 0 {
+				fmt.Println("Expected an error, didn't get one")
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestParseDateOnlyReportsMatchedFormat(t *testing.T) {
+	dates := []struct {
+		Value  string
+		Format string
+	}{
+		{"2006-01-02", ISOCalendarDate},
+		{"2006-W01-1", ISOWeekDate},
+		{"2006-002", ISOOrdinalDate},
+		{"02 Jan 2006", "02 Jan 2006"},
+	}
+
+	want := time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC)
+	for _, d := range dates {
+		got, format, err := ParseDateOnly(d.Value, Options{"mode": "lenient"})
+		if err != nil || format != d.Format || !got.Equal(want) {
+			fmt.Printf("parsing %s: got %v, %q, %v\n", d.Value, got, format, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestDateTimeRulesRejectBadOptions(t *testing.T) {
+	r, _ := http.NewRequest("GET", "localhost?d=2006-01-02", nil)
+	r.ParseForm()
+
+	for _, o := range []Options{{"timezone": "Mars/Olympus_Mons"}, {"mode": "loose"}, {"mode": true}} {
+		for _, check := range []CheckFunc{DateOnly, TimeOnly, DateTime} {
+			if err := check(r, "d", o); !errors.Is(err, ErrMisconfiguredRule) {
+				fmt.Printf("expected a configuration error for %v, got %v\n", o, err)
+				t.FailNow()
+			}
+			if _, err := Compile(Rule{Param: "d", Check: check, Options: o}); !errors.Is(err, ErrMisconfiguredRule) {
+				fmt.Printf("expected Compile to reject %v, got %v\n", o, err)
+				t.FailNow()
+			}
+		}
+	}
+
+	if err := Duration(r, "d", Options{"mode": "loose"}); !errors.Is(err, ErrMisconfiguredRule) {
+		fmt.Println("expected a configuration error for an unknown Duration mode, got", err)
+		t.FailNow()
+	}
+}
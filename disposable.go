@@ -0,0 +1,139 @@
+package validate
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+//go:embed data/disposable.txt
+var disposableData string
+
+var (
+	disposableMu      sync.RWMutex
+	disposableDomains = parseDomainList(disposableData)
+)
+
+// parseDomainList reads one domain per line, skipping blank lines
+// and `#` comments.
+func parseDomainList(data string) map[string]bool {
+	domains := make(map[string]bool)
+	for _, row := range readTable(data) {
+		domains[normalizeDomain(row[0])] = true
+	}
+
+	return domains
+}
+
+func normalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// UpdateDisposableDomains replaces the list of disposable email
+// domains used by DisposableEmail with one read from r, in the same
+// one-domain-per-line format as the embedded list. It is safe to call
+// while requests are being validated.
+func UpdateDisposableDomains(r io.Reader) error {
+	var b strings.Builder
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		b.WriteString(s.Text())
+		b.WriteByte('\n')
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	domains := parseDomainList(b.String())
+
+	disposableMu.Lock()
+	disposableDomains = domains
+	disposableMu.Unlock()
+
+	return nil
+}
+
+// domainListed reports whether the domain, or any domain it is a
+// subdomain of, is in the list.
+func domainListed(domain string, list func(string) bool) bool {
+	for {
+		if list(domain) {
+			return true
+		}
+
+		i := strings.IndexByte(domain, '.')
+		if i < 0 {
+			return false
+		}
+		domain = domain[i+1:]
+	}
+}
+
+func inSlice(domains []string) func(string) bool {
+	return func(domain string) bool {
+		for _, d := range domains {
+			if normalizeDomain(d) == domain {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// DisposableEmail returns an error if the parameter is an email
+// address at a disposable or throwaway email provider, or at any
+// subdomain of one. Providers come from an embedded list, which can
+// be replaced with UpdateDisposableDomains.
+//
+// The address is parsed in the Options' `mode`, as it is by Email.
+// Values that are not addresses, and addresses at an IP address
+// literal, pass, so DisposableEmail should be combined with Email.
+//
+// The Validator's AllowedDomains are never treated as disposable,
+// and its BlockedDomains always are.
+var DisposableEmail CheckFunc = func(r *http.Request, param string, o Options) error {
+	return disposableEmailField(r.Context(), requestField(r, param, o))
+}
+
+func disposableEmailField(ctx context.Context, f Field) error {
+	mode, err := emailMode(f.Options)
+	if err != nil {
+		return misconfigured(f.Name, "%w", err)
+	}
+
+	addr, err := parseEmailMode(f.String(), mode)
+	if err != nil || addr.IPLiteral {
+		return nil
+	}
+
+	domain := normalizeDomain(addr.Domain)
+	if ascii, err := idnaToASCII(domain); err == nil {
+		domain = ascii
+	}
+
+	var allowed, blocked []string
+	if rr := runFor(ctx); rr != nil {
+		allowed, blocked = rr.settings.AllowedDomains, rr.settings.BlockedDomains
+	}
+
+	if domainListed(domain, inSlice(allowed)) {
+		return nil
+	}
+
+	disposableMu.RLock()
+	domains := disposableDomains
+	disposableMu.RUnlock()
+
+	disposable := domainListed(domain, func(d string) bool { return domains[d] })
+	if disposable || domainListed(domain, inSlice(blocked)) {
+		return fmt.Errorf("%s cannot use a disposable email address", f.Name)
+	}
+
+	return nil
+}
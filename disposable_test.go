@@ -0,0 +1,70 @@
+package validate
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDisposableEmail(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+
+	cases := []struct {
+		Value  string
+		Passes bool
+	}{
+		{"me@tomm.us", true},
+		{"me@gmail.com", true},
+		{"me@mailinator.com", false},
+		{"me@MAILINATOR.com", false},
+		{"me@eu.mailinator.com", false},
+		{"me@notmailinator.com", true},
+		{"me@yopmail.com", true},
+		{"me@spammer.example", false},
+		{"me@mail.spammer.example", false},
+		{"not an email", true},
+		{"me@[192.0.2.1]", true},
+		{"jöhn@tomm.us", true},
+		{"jöhn@mailinator.com", false},
+	}
+
+	for _, c := range cases {
+		r.Form.Set("email", c.Value)
+		v := Make(r, Rule{Param: "email", Check: DisposableEmail, Options: Options{"mode": EmailIntl}})
+		v.AllowedDomains = []string{"yopmail.com"}
+		v.BlockedDomains = []string{"Spammer.Example."}
+
+		msgs, _ := v.Run()
+		if c.Passes != (len(msgs) == 0) {
+			fmt.Println("unexpected result for", c.Value, msgs)
+			t.FailNow()
+		}
+	}
+}
+
+func TestUpdateDisposableDomains(t *testing.T) {
+	defer func(domains map[string]bool) { disposableDomains = domains }(disposableDomains)
+
+	err := UpdateDisposableDomains(strings.NewReader("# updated list\nnewthrowaway.example\n"))
+	if err != nil {
+		fmt.Println("unexpected error updating the list:", err)
+		t.FailNow()
+	}
+
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+
+	r.Form.Set("email", "me@newthrowaway.example")
+	if msgs, _ := Check(r, Rule{Param: "email", Check: DisposableEmail, Options: nil}); len(msgs) == 0 {
+		fmt.Println("expected the updated list to be used")
+		t.FailNow()
+	}
+
+	r.Form.Set("email", "me@mailinator.com")
+	if msgs, _ := Check(r, Rule{Param: "email", Check: DisposableEmail, Options: nil}); len(msgs) > 0 {
+		fmt.Println("expected the embedded list to be replaced")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,453 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// The modes that Email can validate addresses in.
+const (
+	// EmailRFC5322 accepts an RFC 5322 addr-spec: a dot-atom or
+	// quoted-string local part, and a host name or address literal
+	// domain as required by RFC 5321.
+	EmailRFC5322 = "rfc5322"
+	// EmailHTML5 accepts the addresses that browsers accept for an
+	// `<input type="email">`.
+	EmailHTML5 = "html5"
+	// EmailIntl is EmailRFC5322 extended by RFC 6531 to allow UTF-8
+	// in the local part and internationalised domain names.
+	EmailIntl = "intl"
+)
+
+// html5Email is the pattern from the WHATWG HTML specification.
+var html5Email = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+
+// domainEmail are the Options used to check addresses before their
+// domain is looked up, so IP address literals are rejected.
+var domainEmail = Options{"reject_ip_literal": true}
+
+// Email returns an error if the parameter value is not a valid
+// email address. Addresses are parsed according to the `mode` in
+// the Options map, which is one of EmailRFC5322 (the default),
+// EmailHTML5 or EmailIntl.
+//
+// The domain must have a top level domain, such as `.com`, unless
+// `"require_tld": false` is passed, so addresses at a single label
+// host such as `me@localhost` are rejected by default, which the
+// pattern that Email used before it had modes accepted. An unknown
+// `mode` makes the rule misconfigured. IP address literals such as
+// `me@[192.0.2.1]`, plus addressing such as `me+tag@example.com`
+// and quoted local parts such as `"me"@example.com` can each be
+// rejected by passing true for the `reject_ip_literal`,
+// `reject_plus` and `reject_quoted` keys.
+var Email CheckFunc = func(r *http.Request, param string, o Options) error {
+	return emailField(r.Context(), requestField(r, param, o))
+}
+
+func emailField(ctx context.Context, f Field) error {
+	if _, err := emailMode(f.Options); err != nil {
+		return misconfigured(f.Name, "%w", err)
+	}
+
+	if err := checkEmail(f.String(), f.Options); err != nil {
+		return fmt.Errorf("%s is not a valid email address", f.Name)
+	}
+
+	return nil
+}
+
+// domainEmailField checks the field is an email address with a domain
+// to look up, whatever Options the rule was given.
+func domainEmailField(ctx context.Context, f Field) error {
+	f.Options = domainEmail
+	return emailField(ctx, f)
+}
+
+// emailAddress is an email address split into its parts.
+type emailAddress struct {
+	Local     string
+	Domain    string
+	Quoted    bool
+	IPLiteral bool
+}
+
+// emailMode returns the `mode` in the Options map, or an error if it
+// is not one of the Email* modes.
+func emailMode(o Options) (string, error) {
+	mode, ok := o["mode"].(string)
+	if _, set := o["mode"]; set && !ok {
+		return "", errors.New("mode must be a string")
+	}
+
+	switch mode {
+	case "", EmailRFC5322, EmailHTML5, EmailIntl:
+		return mode, nil
+	}
+
+	return "", fmt.Errorf("unknown email mode %q", mode)
+}
+
+func checkEmail(value string, o Options) error {
+	mode, err := emailMode(o)
+	if err != nil {
+		return err
+	}
+	requireTLD, ok := o["require_tld"].(bool)
+	if !ok {
+		requireTLD = true
+	}
+
+	addr, err := parseEmailMode(value, mode)
+	if err != nil {
+		return err
+	}
+
+	if reject, _ := o["reject_ip_literal"].(bool); reject && addr.IPLiteral {
+		return fmt.Errorf("%q has an IP address domain", value)
+	}
+
+	if reject, _ := o["reject_quoted"].(bool); reject && addr.Quoted {
+		return fmt.Errorf("%q has a quoted local part", value)
+	}
+
+	if reject, _ := o["reject_plus"].(bool); reject && strings.Contains(addr.Local, "+") {
+		return fmt.Errorf("%q uses plus addressing", value)
+	}
+
+	if requireTLD && !addr.IPLiteral && !hasTLD(addr.Domain) {
+		return fmt.Errorf("%q does not have a top level domain", value)
+	}
+
+	return nil
+}
+
+// parseEmailMode parses an address in one of the Email* modes.
+func parseEmailMode(value string, mode string) (emailAddress, error) {
+	switch mode {
+	case EmailHTML5:
+		if !html5Email.MatchString(value) {
+			return emailAddress{}, fmt.Errorf("%q does not match the HTML5 email pattern", value)
+		}
+		i := strings.LastIndexByte(value, '@')
+		return emailAddress{Local: value[:i], Domain: value[i+1:]}, nil
+	case EmailIntl:
+		return parseEmail(value, true)
+	}
+
+	return parseEmail(value, false)
+}
+
+// parseEmail parses an addr-spec without comments or folding white
+// space. When intl is true, UTF-8 is allowed as described in
+// RFC 6531 and the domain can be an internationalised domain name.
+func parseEmail(value string, intl bool) (emailAddress, error) {
+	var addr emailAddress
+
+	if !utf8.ValidString(value) {
+		return addr, fmt.Errorf("%q is not valid UTF-8", value)
+	}
+
+	var rest string
+	if strings.HasPrefix(value, `"`) {
+		end, ok := quotedStringEnd(value, intl)
+		if !ok {
+			return addr, fmt.Errorf("%q has an invalid quoted local part", value)
+		}
+		addr.Local, addr.Quoted, rest = value[:end], true, value[end:]
+	} else {
+		i := strings.IndexByte(value, '@')
+		if i < 0 {
+			return addr, fmt.Errorf("%q is missing an @", value)
+		}
+		addr.Local, rest = value[:i], value[i:]
+		if !isDotAtom(addr.Local, intl) {
+			return addr, fmt.Errorf("%q has an invalid local part", value)
+		}
+	}
+
+	if !strings.HasPrefix(rest, "@") {
+		return addr, fmt.Errorf("%q is missing an @", value)
+	}
+	addr.Domain = rest[1:]
+
+	// RFC 5321 limits the length of each part of a path.
+	if len(addr.Local) > 64 || len(value) > 254 {
+		return addr, fmt.Errorf("%q is too long", value)
+	}
+
+	if strings.HasPrefix(addr.Domain, "[") && strings.HasSuffix(addr.Domain, "]") {
+		if !isAddressLiteral(addr.Domain[1 : len(addr.Domain)-1]) {
+			return addr, fmt.Errorf("%q has an invalid address literal", value)
+		}
+		addr.IPLiteral = true
+		return addr, nil
+	}
+
+	domain := addr.Domain
+	if intl {
+		ascii, err := idnaToASCII(domain)
+		if err != nil {
+			return addr, err
+		}
+		domain = ascii
+	}
+
+	if !isHostname(domain) {
+		return addr, fmt.Errorf("%q has an invalid domain", value)
+	}
+
+	return addr, nil
+}
+
+// isAtext reports whether c can be used in an atom.
+func isAtext(c rune, intl bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	case strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", c):
+		return true
+	}
+
+	return intl && c >= utf8.RuneSelf
+}
+
+// isDotAtom reports whether s is one or more atoms joined by dots.
+func isDotAtom(s string, intl bool) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, atom := range strings.Split(s, ".") {
+		if atom == "" {
+			return false
+		}
+		for _, c := range atom {
+			if !isAtext(c, intl) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// quotedStringEnd returns the index just after the quoted string
+// at the start of s.
+func quotedStringEnd(s string, intl bool) (int, bool) {
+	for i := 1; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case c == '"':
+			return i + 1, true
+		case c == '\\':
+			// A quoted-pair escapes any printable character or space.
+			next, n := utf8.DecodeRuneInString(s[i+1:])
+			if n == 0 || !isQtext(next, intl) && next != '"' && next != '\\' {
+				return 0, false
+			}
+			i += 1 + n
+			continue
+		case !isQtext(c, intl):
+			return 0, false
+		}
+		i += size
+	}
+
+	return 0, false
+}
+
+// isQtext reports whether c can appear unescaped in a quoted string.
+func isQtext(c rune, intl bool) bool {
+	switch {
+	case c == '"' || c == '\\':
+		return false
+	case c == ' ' || c == '\t' || (c > ' ' && c <= '~'):
+		return true
+	}
+
+	return intl && c >= utf8.RuneSelf
+}
+
+// isAddressLiteral reports whether s is the inside of an RFC 5321
+// address literal, such as `192.0.2.1` or `IPv6:2001:db8::1`.
+func isAddressLiteral(s string) bool {
+	if strings.HasPrefix(s, "IPv6:") {
+		ip := net.ParseIP(s[5:])
+		return ip != nil && strings.Contains(s[5:], ":")
+	}
+
+	ip := net.ParseIP(s)
+	return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+}
+
+// isHostname reports whether s is made up of letter, digit and
+// hyphen labels that are each at most 63 characters long.
+func isHostname(s string) bool {
+	if s == "" || len(s) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// hasTLD reports whether a domain has more than one label, and its
+// last label is not entirely numeric.
+func hasTLD(domain string) bool {
+	i := strings.LastIndexByte(domain, '.')
+	if i < 0 || i == len(domain)-1 {
+		return false
+	}
+
+	return !isDigits(domain[i+1:])
+}
+
+// idnaToASCII converts each label of an internationalised domain
+// name to its ASCII compatible `xn--` form, as described in RFC 5891.
+// Labels are lower cased, but no further Unicode mapping is done.
+func idnaToASCII(domain string) (string, error) {
+	labels := strings.Split(domain, ".")
+	for i, label := range labels {
+		ascii := true
+		for j := 0; j < len(label); j++ {
+			if label[j] >= utf8.RuneSelf {
+				ascii = false
+				break
+			}
+		}
+		if ascii {
+			continue
+		}
+
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", fmt.Errorf("label %q cannot start or end with a hyphen", label)
+		}
+
+		encoded, err := punycode(strings.ToLower(label))
+		if err != nil {
+			return "", err
+		}
+		labels[i] = "xn--" + encoded
+	}
+
+	return strings.Join(labels, "."), nil
+}
+
+// punycode encodes a label using the algorithm in RFC 3492.
+func punycode(label string) (string, error) {
+	const (
+		base        = 36
+		tmin        = 1
+		tmax        = 26
+		skew        = 38
+		damp        = 700
+		initialBias = 72
+		initialN    = 128
+	)
+
+	adapt := func(delta int, numPoints int, first bool) int {
+		if first {
+			delta /= damp
+		} else {
+			delta /= 2
+		}
+		delta += delta / numPoints
+
+		k := 0
+		for delta > ((base-tmin)*tmax)/2 {
+			delta /= base - tmin
+			k += base
+		}
+
+		return k + (base-tmin+1)*delta/(delta+skew)
+	}
+
+	digit := func(d int) byte {
+		if d < 26 {
+			return byte('a' + d)
+		}
+		return byte('0' + d - 26)
+	}
+
+	runes := []rune(label)
+	var out []byte
+	for _, r := range runes {
+		if r < initialN {
+			out = append(out, byte(r))
+		}
+	}
+
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := initialN, 0, initialBias
+	for handled < len(runes) {
+		m := int(utf8.MaxRune) + 1
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+
+		delta += (m - n) * (handled + 1)
+		n = m
+
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+
+			q := delta
+			for k := base; ; k += base {
+				t := k - bias
+				if t < tmin {
+					t = tmin
+				} else if t > tmax {
+					t = tmax
+				}
+				if q < t {
+					break
+				}
+				out = append(out, digit(t+(q-t)%(base-t)))
+				q = (q - t) / (base - t)
+			}
+			out = append(out, digit(q))
+
+			bias = adapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+
+		delta++
+		n++
+	}
+
+	if len(out) > 59 {
+		return "", fmt.Errorf("label %q is too long", label)
+	}
+
+	return string(out), nil
+}
@@ -0,0 +1,109 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEmailModes(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+
+	rules := []struct {
+		Passes  []string
+		Fails   []string
+		Options Options
+	}{
+		{
+			[]string{
+				"me@tomm.us", "me+99__.asd@subdomain.tomm.us", `"john doe"@example.com`,
+				`"a@b"@example.com`, `"quote\"d"@example.com`, `""@example.com`, "me@[192.0.2.1]",
+				"me@[IPv6:2001:db8::1]", "o'brien@example.co.uk", "x@xn--bcher-kva.example",
+			},
+			[]string{
+				"", "a@b", `"@x`, "juststring", "me@something@tomm.us", "me space@tomm.us",
+				".me@example.com", "me.@example.com", "me..me@example.com", "me@-example.com",
+				"me@example..com", "me@example.123", "me@[300.0.0.1]", "me@[2001:db8::1]",
+				"müller@example.com", "me@bücher.example", strings.Repeat("a", 65) + "@example.com",
+				"me@" + strings.Repeat("a", 64) + ".com", `"unterminated@example.com`,
+			},
+			nil,
+		},
+		{
+			[]string{"a@b", "me@localhost"},
+			[]string{"a@", "@b"},
+			Options{"require_tld": false},
+		},
+		{
+			[]string{"me@tomm.us", "me+tag@tomm.us", "a@b"},
+			[]string{`"john doe"@example.com`, "me@[192.0.2.1]", "me@-example.com", "me@bücher.example"},
+			Options{"mode": EmailHTML5, "require_tld": false},
+		},
+		{
+			[]string{"müller@example.com", "me@bücher.example", "用户@例子.广告", `"ü"@example.com`},
+			[]string{"me@bü cher.example", "me@-bücher.example", "a@b"},
+			Options{"mode": EmailIntl},
+		},
+		{
+			[]string{"me@tomm.us"},
+			[]string{"me+tag@tomm.us", `"me"@tomm.us`, "me@[192.0.2.1]"},
+			Options{"reject_plus": true, "reject_quoted": true, "reject_ip_literal": true},
+		},
+	}
+
+	for _, rule := range rules {
+		for _, value := range rule.Passes {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: Email, Options: rule.Options})
+			if len(msgs) > 0 {
+				fmt.Println("Got an error, expected none:", msgs["parameter"])
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+
+		for _, value := range rule.Fails {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: Email, Options: rule.Options})
+			if len(msgs) == 0 {
+				fmt.Println("Expected an error, didn't get one")
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestIDNAToASCII(t *testing.T) {
+	domains := map[string]string{
+		"bücher.example": "xn--bcher-kva.example",
+		"例子.广告":          "xn--fsqu00a.xn--4rr70v",
+		"münchen.de":     "xn--mnchen-3ya.de",
+		"example.com":    "example.com",
+	}
+
+	for domain, want := range domains {
+		if got, err := idnaToASCII(domain); err != nil || got != want {
+			fmt.Printf("converting %s: got %s, %v; want %s\n", domain, got, err, want)
+			t.FailNow()
+		}
+	}
+}
+
+func TestEmailModeMustBeKnown(t *testing.T) {
+	r, _ := http.NewRequest("GET", "localhost?email=me%40tomm.us", nil)
+
+	_, err := Check(r, Rule{Param: "email", Check: Email, Options: Options{"mode": "html"}})
+	if !errors.Is(err, ErrMisconfiguredRule) {
+		fmt.Println("expected an unknown mode to be misconfigured, got", err)
+		t.FailNow()
+	}
+
+	if _, err := Compile(Rule{Param: "email", Check: Email, Options: Options{"mode": "html"}}); !errors.Is(err, ErrMisconfiguredRule) {
+		fmt.Println("expected Compile to reject an unknown mode, got", err)
+		t.FailNow()
+	}
+}
@@ -1,12 +1,134 @@
 package validate
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type Error string
 
 const (
 	EmptyRuleset     Error = "attempted to run a validator with an empty rule set"
 	ValidationFailed Error = "validation failed"
+	// ErrMisconfiguredRule is matched by the errors that Run returns
+	// when a rule cannot be checked because of the way that it was
+	// set up, such as Regex without a `pattern`, rather than because
+	// of the request. Use errors.Is to check for it.
+	ErrMisconfiguredRule Error = "misconfigured rule"
+	// ErrCheckFailed is matched by the errors that Run returns when
+	// a rule could not be checked because something it depends on
+	// failed, such as a ValueSource whose database is unavailable.
+	// Use errors.Is to check for it.
+	ErrCheckFailed Error = "rule could not be checked"
 )
 
 func (e Error) Error() string {
 	return string(e)
 }
+
+// RuleError describes a misconfigured rule. CheckFuncs return one
+// when their Options cannot be used, and Run returns it as an error
+// instead of adding it to the Message, so that the problem reaches
+// logs and tests rather than users. It matches ErrMisconfiguredRule.
+type RuleError struct {
+	// Rule is the rule's name, such as `regex`, if it has one. See
+	// Rule.Name.
+	Rule  string
+	Param string
+	Err   error
+}
+
+func (e *RuleError) Error() string {
+	if e.Rule == "" {
+		return fmt.Sprintf("misconfigured rule for %s: %v", e.Param, e.Err)
+	}
+
+	return fmt.Sprintf("misconfigured %s rule for %s: %v", e.Rule, e.Param, e.Err)
+}
+
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RuleError) Is(target error) bool {
+	return target == ErrMisconfiguredRule
+}
+
+// misconfigured returns a RuleError for the parameter. The name of
+// the rule is added by the Validator.
+func misconfigured(param string, format string, a ...interface{}) error {
+	return &RuleError{Param: param, Err: fmt.Errorf(format, a...)}
+}
+
+// CheckError describes a rule that could not be checked, through
+// no fault of the request. Like a RuleError, Run returns it as an
+// error instead of adding it to the Message. It matches
+// ErrCheckFailed.
+type CheckError struct {
+	Param string
+	Err   error
+}
+
+func (e *CheckError) Error() string {
+	return fmt.Sprintf("unable to check %s: %v", e.Param, e.Err)
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
+func (e *CheckError) Is(target error) bool {
+	return target == ErrCheckFailed
+}
+
+// ItemErrors is returned by rules that check each of a parameter's
+// values, such as Each and Distinct. It holds the error for each
+// value that failed, by its index. The Validator reports each error
+// under the parameter's name followed by the index, such as `tags.1`.
+type ItemErrors map[int]error
+
+func (e ItemErrors) Error() string {
+	var msgs []string
+	for _, i := range e.indexes() {
+		msgs = append(msgs, e[i].Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func (e ItemErrors) indexes() []int {
+	indexes := make([]int, 0, len(e))
+	for i := range e {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	return indexes
+}
+
+// PathErrors is returned by rules that check structured values, such
+// as a JSON body. It holds an error for each path inside the value
+// that failed, such as `address.city`. The Validator reports each
+// error under the Rule's name followed by the path. An empty path
+// is the value itself.
+type PathErrors map[string]error
+
+func (e PathErrors) Error() string {
+	var msgs []string
+	for _, path := range e.paths() {
+		msgs = append(msgs, e[path].Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func (e PathErrors) paths() []string {
+	paths := make([]string, 0, len(e))
+	for path := range e {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
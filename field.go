@@ -0,0 +1,429 @@
+package validate
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// Field is a named value that is being validated, independent of
+// where the value came from.
+type Field struct {
+	// Name is the name of the field, such as `email` or, for nested
+	// values, `address.city`.
+	Name string
+	// Values are the field's values as strings. Slices of values
+	// have more than one.
+	Values []string
+	// Value is the field's original value, such as an int from a
+	// struct, if the data being validated was typed. Otherwise it
+	// is the field's first value as a string.
+	Value interface{}
+	// Exists is false if the field was not in the data at all.
+	Exists bool
+	// Options are the Options of the Rule being checked.
+	Options Options
+	// Data holds all of the values being validated, for rules that
+	// compare fields.
+	Data url.Values
+	// Files are the files uploaded for the field, if it is from a
+	// request's Form or Body.
+	Files []*multipart.FileHeader
+
+	// request is the request that the Field was read from by a
+	// CheckFunc, if it was.
+	request *http.Request
+}
+
+// String returns the field's first value, or an empty string if it
+// does not have one.
+func (f Field) String() string {
+	if len(f.Values) == 0 {
+		return ""
+	}
+
+	return f.Values[0]
+}
+
+// FieldFunc is a rule that checks a Field, without needing to know
+// whether it came from a request, a map or a struct. Each of the built
+// in CheckFuncs is adapted from a FieldFunc, which its FieldFunc
+// method returns.
+type FieldFunc func(ctx context.Context, f Field) error
+
+// CheckFunc adapts the FieldFunc so that it can be used as the Check
+// of a Rule. The Field is read from the Form of the request that the
+// CheckFunc is given, and the context is the request's context.
+func (fn FieldFunc) CheckFunc() CheckFunc {
+	return func(r *http.Request, param string, o Options) error {
+		return fn(r.Context(), requestField(r, param, o))
+	}
+}
+
+// fieldFuncs are the FieldFuncs that the built in CheckFuncs are
+// adapted from, by name. Rules that use a built in CheckFunc call it
+// directly, so that Validate does not need a request to check them.
+var fieldFuncs = map[string]FieldFunc{
+	"required":         requiredField,
+	"empty":            emptyField,
+	"alpha":            alphaField,
+	"alphanumeric":     alphanumericField,
+	"integer":          integerField,
+	"boolean":          booleanField,
+	"max_length":       maxLengthField,
+	"min_length":       minLengthField,
+	"regex":            regexField,
+	"not_regex":        notRegexField,
+	"email":            emailField,
+	"mx_email":         mxEmailField,
+	"telnet_email":     telnetEmailField,
+	"disposable_email": disposableEmailField,
+	"rfc3339":          rfc3339Field,
+	"rfc1123":          rfc1123Field,
+	"rfc822":           rfc822Field,
+	"unix_date":        unixDateField,
+	"date_format":      dateFormatField,
+	"date":             dateField,
+	"date_only":        dateOnlyField,
+	"time_only":        timeOnlyField,
+	"date_time":        dateTimeField,
+	"duration":         durationField,
+	"before":           beforeField,
+	"after":            afterField,
+	"between":          betweenField,
+	"min_age":          minAgeField,
+	"max_age":          maxAgeField,
+	"weekday":          weekdayField,
+	"business_day":     businessDayField,
+	"country":          countryField,
+	"language":         languageField,
+	"timezone":         timezoneField,
+	"postal_code":      postalCodeField,
+	"phone":            phoneField,
+	"in":               inField,
+	"not_in":           notInField,
+	"in_fold":          inFoldField,
+	"not_in_fold":      notInFoldField,
+	"list":             listField,
+	"min_items":        minItemsField,
+	"max_items":        maxItemsField,
+	"distinct":         distinctField,
+	"required_file":    requiredFileField,
+	"max_file_size":    maxFileSizeField,
+	"min_file_size":    minFileSizeField,
+	"max_files":        maxFilesField,
+	"file_extension":   fileExtensionField,
+	"mime_type":        mimeTypeField,
+	"image":            imageField,
+	"image_dimensions": imageDimensionsField,
+	"aspect_ratio":     aspectRatioField,
+	"zip":              zipField,
+	"pdf":              pdfField,
+}
+
+// FieldFunc returns the FieldFunc that a built in CheckFunc is
+// adapted from, so that it can check a Field directly. It returns
+// false for any other CheckFunc.
+func (check CheckFunc) FieldFunc() (FieldFunc, bool) {
+	if check == nil {
+		return nil, false
+	}
+
+	fn, ok := fieldFuncs[builtinNames[funcID(check)]]
+	return fn, ok
+}
+
+// requestField returns the Field for a parameter in the request's
+// Form. When the request's rule is being checked by Validate, the
+// Field's Value is the typed value from the data.
+func requestField(r *http.Request, param string, o Options) Field {
+	values, exists := r.Form[param]
+	f := Field{Name: param, Values: values, Exists: exists, Options: o, Data: r.Form, Files: files(r, param), request: r}
+
+	if rr := runFor(r.Context()); rr != nil && rr.raw != nil && rr.source == Form {
+		f.Value = rr.raw[param]
+	}
+	if f.Value == nil && len(values) > 0 {
+		f.Value = values[0]
+	}
+
+	return f
+}
+
+// Validate is an all-in-one method of validating data that did not
+// come from a request, such as command line input, a queue message
+// or a config file. See the Validator's Validate method for the types
+// of data that can be validated.
+func Validate(ctx context.Context, data interface{}, rule ...Rule) (Message, error) {
+	return (&Validator{Rules: rule}).Validate(ctx, data)
+}
+
+// Validate determines if the given rules are satisfied by data,
+// which can be url.Values, a map[string][]string, map[string]string
+// or map[string]interface{}, or a struct or a pointer to one.
+//
+// Nested maps and structs are named with dotted paths, such as
+// `address.city`, and slices of them are indexed, as in
+// `items.0.name`. Slices of other values give the field more than
+// one value. Struct fields are named by their `json` tag if they
+// have one. Numbers and booleans are formatted as strings, and
+// values that implement encoding.TextMarshaler or fmt.Stringer are
+// converted using it. Nil pointers are treated as missing fields,
+// but any other zero value is present, so optional struct fields
+// should be pointers for Required to be useful.
+//
+// Built in rules are given each Field directly. Other CheckFuncs are
+// given a request whose Form holds the data, so that they work
+// unchanged, but rules that read another Source, or uploaded files,
+// find nothing. Validate does not change the Validator, so Validated
+// and Filtered only describe calls to Run, and it can be called from
+// several goroutines.
+func (v *Validator) Validate(ctx context.Context, data interface{}) (Message, error) {
+	if ctx == nil {
+		return nil, errors.New("validate: nil Context")
+	}
+	if len(v.Rules) == 0 {
+		return nil, EmptyRuleset
+	}
+
+	fd, err := flatten(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.check(&run{ctx: ctx, settings: v, raw: fd.Raw, listed: fd.Lists, values: map[Source]url.Values{Form: fd.Values}})
+}
+
+// Flatten converts data to url.Values in the way described by the
+// Validator's Validate method.
+func Flatten(data interface{}) (url.Values, error) {
+	fd, err := flatten(data)
+	return fd.Values, err
+}
+
+// Flattened is data that has already been flattened, which Validate
+// uses as it is. Raw holds the original value of each field by its
+// name, which is how FieldFuncs receive typed values, and Lists holds
+// the names of the fields that were slices, so that List passes for
+// them. Code generated by validategen passes its data to Validate as
+// Flattened, so that no reflection is needed.
+//
+// If Lists is nil, the slices in Raw are found using reflection.
+type Flattened struct {
+	Values url.Values
+	Raw    map[string]interface{}
+	Lists  map[string]bool
+}
+
+func flatten(data interface{}) (Flattened, error) {
+	switch data := data.(type) {
+	case Flattened:
+		if data.Lists == nil && data.Raw != nil {
+			data.Lists = make(map[string]bool)
+			for name, value := range data.Raw {
+				if isList(reflect.ValueOf(value)) {
+					data.Lists[name] = true
+				}
+			}
+		}
+		return data, nil
+	case url.Values:
+		return Flattened{Values: copyValues(data)}, nil
+	case map[string][]string:
+		return Flattened{Values: copyValues(data)}, nil
+	case map[string]string:
+		values := make(url.Values, len(data))
+		for name, value := range data {
+			values.Set(name, value)
+		}
+		return Flattened{Values: values}, nil
+	}
+
+	rv := reflect.ValueOf(data)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct && !(rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String) {
+		return Flattened{}, fmt.Errorf("unable to validate data of type %T", data)
+	}
+
+	f := &flattener{values: make(url.Values), raw: make(map[string]interface{}), lists: make(map[string]bool)}
+	f.walk("", rv)
+
+	return Flattened{Values: f.values, Raw: f.raw, Lists: f.lists}, nil
+}
+
+type flattener struct {
+	values url.Values
+	raw    map[string]interface{}
+	lists  map[string]bool
+}
+
+// isList reports whether a value is a slice or array that gives a
+// field several values, rather than bytes that give it one.
+func isList(rv reflect.Value) bool {
+	return rv.Kind() == reflect.Array || rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8
+}
+
+func (f *flattener) walk(name string, rv reflect.Value) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+
+	if name != "" {
+		if s, ok := scalar(rv); ok {
+			f.values.Add(name, s)
+			f.setRaw(name, rv)
+			return
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		f.setRaw(name, rv)
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			f.values.Add(name, string(rv.Bytes()))
+			return
+		}
+		if name != "" {
+			f.lists[name] = true
+		}
+
+		if _, ok := f.values[name]; !ok {
+			f.values[name] = []string{}
+		}
+		for i := 0; i < rv.Len(); i++ {
+			elem := rv.Index(i)
+			if s, ok := scalar(deref(elem)); ok {
+				f.values.Add(name, s)
+			} else {
+				f.walk(join(name, strconv.Itoa(i)), elem)
+			}
+		}
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return
+		}
+		f.setRaw(name, rv)
+		iter := rv.MapRange()
+		for iter.Next() {
+			f.walk(join(name, iter.Key().String()), iter.Value())
+		}
+	case reflect.Struct:
+		f.setRaw(name, rv)
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() && !(sf.Anonymous && sf.Type.Kind() == reflect.Struct) {
+				continue
+			}
+
+			field, tagged := jsonName(sf)
+			if field == "-" {
+				continue
+			}
+			if sf.Anonymous && !tagged && deref(rv.Field(i)).Kind() == reflect.Struct {
+				// Embedded structs are promoted, as they are by
+				// encoding/json.
+				f.walk(name, rv.Field(i))
+				continue
+			}
+			f.walk(join(name, field), rv.Field(i))
+		}
+	}
+}
+
+// setRaw records the original value of a field, unless it has one
+// already because it is an embedded struct.
+func (f *flattener) setRaw(name string, rv reflect.Value) {
+	if _, ok := f.raw[name]; ok || name == "" || !rv.CanInterface() {
+		return
+	}
+
+	f.raw[name] = rv.Interface()
+}
+
+// jsonName returns the name that encoding/json would give a struct
+// field, and whether it came from a tag.
+func jsonName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "-", true
+	}
+
+	for i := 0; i < len(tag); i++ {
+		if tag[i] == ',' {
+			tag = tag[:i]
+			break
+		}
+	}
+	if tag != "" {
+		return tag, true
+	}
+
+	return sf.Name, false
+}
+
+// scalar returns the string form of a value that is not made up of
+// other values.
+func scalar(rv reflect.Value) (string, bool) {
+	if !rv.IsValid() || (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil() {
+		return "", false
+	}
+
+	// Values from unexported fields can only be read by kind.
+	if rv.CanInterface() {
+		switch value := rv.Interface().(type) {
+		case encoding.TextMarshaler:
+			text, err := value.MarshalText()
+			return string(text), err == nil
+		case fmt.Stringer:
+			return value.String(), true
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits()), true
+	}
+
+	return "", false
+}
+
+func deref(rv reflect.Value) reflect.Value {
+	for (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	return rv
+}
+
+// join joins the segments of a dotted path.
+func join(name string, field string) string {
+	if name == "" {
+		return field
+	}
+	if field == "" {
+		return name
+	}
+
+	return name + "." + field
+}
@@ -0,0 +1,233 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type address struct {
+	City     string `json:"city"`
+	Postcode string `json:"postcode"`
+}
+
+type timestamps struct {
+	Created time.Time `json:"created"`
+}
+
+type signup struct {
+	timestamps
+	Email    string    `json:"email"`
+	Age      int       `json:"age"`
+	Nickname *string   `json:"nickname,omitempty"`
+	Tags     []string  `json:"tags"`
+	Address  address   `json:"address"`
+	Previous []address `json:"previous"`
+	Password string    `json:"-"`
+	internal string
+}
+
+func TestFlatten(t *testing.T) {
+	s := signup{
+		timestamps: timestamps{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		Email:      "me@tomm.us",
+		Age:        42,
+		Tags:       []string{"a", "b"},
+		Address:    address{"Leeds", "LS1 1AA"},
+		Previous:   []address{{City: "York"}},
+		Password:   "secret",
+		internal:   "x",
+	}
+
+	want := url.Values{
+		"created":             {"2020-01-02T03:04:05Z"},
+		"email":               {"me@tomm.us"},
+		"age":                 {"42"},
+		"tags":                {"a", "b"},
+		"address.city":        {"Leeds"},
+		"address.postcode":    {"LS1 1AA"},
+		"previous":            {},
+		"previous.0.city":     {"York"},
+		"previous.0.postcode": {""},
+	}
+
+	got, err := Flatten(&s)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		fmt.Println("unexpected values:", got, err)
+		t.FailNow()
+	}
+
+	got, _ = Flatten(map[string]interface{}{"n": 1.5, "ok": true, "nested": map[string]interface{}{"list": []interface{}{1, "two"}}})
+	want = url.Values{"n": {"1.5"}, "ok": {"true"}, "nested.list": {"1", "two"}}
+	if !reflect.DeepEqual(got, want) {
+		fmt.Println("unexpected values:", got)
+		t.FailNow()
+	}
+
+	type wrapper struct {
+		Meta struct{ timestamps } `json:"meta"`
+	}
+	got, _ = Flatten(wrapper{})
+	if want := (url.Values{"meta.created": {"0001-01-01T00:00:00Z"}}); !reflect.DeepEqual(got, want) {
+		fmt.Println("unexpected values:", got)
+		t.FailNow()
+	}
+
+	if _, err := Flatten(42); err == nil {
+		fmt.Println("expected an error flattening an int")
+		t.FailNow()
+	}
+}
+
+func TestValidate(t *testing.T) {
+	rules := []Rule{
+		{Param: "email", Check: Email},
+		{Param: "age", Check: Integer},
+		{Param: "nickname", Check: Required},
+		{Param: "address.city", Check: In, Options: Options{"values": []string{"Leeds", "York"}}},
+	}
+
+	nickname := "tom"
+	msgs, err := Validate(context.Background(), signup{Email: "me@tomm.us", Age: 42, Nickname: &nickname, Address: address{City: "Leeds"}}, rules...)
+	if err != nil || len(msgs) > 0 {
+		fmt.Println("unexpected errors:", msgs, err)
+		t.FailNow()
+	}
+
+	msgs, _ = Validate(context.Background(), signup{Email: "not an email", Address: address{City: "Hull"}}, rules...)
+	for _, key := range []string{"email", "nickname", "address.city"} {
+		if len(msgs[key]) == 0 {
+			fmt.Println("expected an error for", key, "got", msgs)
+			t.FailNow()
+		}
+	}
+
+	msgs, _ = Validate(context.Background(), map[string]string{"email": "me@tomm.us"}, Rule{Param: "email", Check: Email, Filters: []Filter{Trim}})
+	if len(msgs) > 0 {
+		fmt.Println("unexpected errors validating a map:", msgs)
+		t.FailNow()
+	}
+
+	if _, err := Validate(context.Background(), "email"); err != EmptyRuleset {
+		fmt.Println("expected an empty ruleset error, got", err)
+		t.FailNow()
+	}
+}
+
+func TestFieldFunc(t *testing.T) {
+	adult := FieldFunc(func(ctx context.Context, f Field) error {
+		if age, ok := f.Value.(int); ok && age >= 18 {
+			return nil
+		}
+		if f.String() == "18+" {
+			return nil
+		}
+		return errors.New(f.Name + " must be an adult")
+	}).CheckFunc()
+
+	v := &Validator{Rules: []Rule{{Param: "age", Check: adult}}}
+	if msgs, _ := v.Validate(context.Background(), map[string]interface{}{"age": 21}); len(msgs) > 0 {
+		fmt.Println("expected the typed value to be used, got", msgs)
+		t.FailNow()
+	}
+
+	r, _ := http.NewRequest("POST", "localhost", strings.NewReader("age=18%2B"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if msgs, _ := Check(r, Rule{Param: "age", Check: adult}); len(msgs) > 0 {
+		fmt.Println("expected the request's value to be used, got", msgs)
+		t.FailNow()
+	}
+
+	if msgs, _ := v.Validate(context.Background(), url.Values{"age": {"12"}}); len(msgs["age"]) == 0 {
+		fmt.Println("expected an error for a child")
+		t.FailNow()
+	}
+
+	integer, ok := Integer.FieldFunc()
+	if !ok || integer(context.Background(), Field{Name: "age", Values: []string{"x"}}) == nil {
+		fmt.Println("expected Integer's FieldFunc to check a Field")
+		t.FailNow()
+	}
+	if _, ok := adult.FieldFunc(); ok {
+		fmt.Println("expected no FieldFunc for a CheckFunc that is not built in")
+		t.FailNow()
+	}
+}
+
+func TestValidateConcurrently(t *testing.T) {
+	v := &Validator{Rules: []Rule{
+		{Param: "age", Check: Integer},
+		{Param: "tags", Check: List},
+	}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			data := map[string]interface{}{"age": i, "tags": []string{"a"}}
+			if i%2 == 1 {
+				data["age"] = "x"
+			}
+			msgs, _ := v.Validate(context.Background(), data)
+			if (len(msgs) > 0) != (i%2 == 1) {
+				t.Errorf("data %d got %v", i, msgs)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestValidateWithoutRequest(t *testing.T) {
+	if _, err := Validate(nil, map[string]string{"age": "1"}, Rule{Param: "age", Check: Integer}); err == nil {
+		fmt.Println("expected an error for a nil Context")
+		t.FailNow()
+	}
+
+	r, _ := http.NewRequest("POST", "localhost", strings.NewReader("age=x"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	v := Make(r, Rule{Param: "age", Check: Integer})
+	if msgs, _ := v.Validate(context.Background(), map[string]interface{}{"age": 42}); len(msgs) > 0 {
+		fmt.Println("expected the data rather than the request to be validated, got", msgs)
+		t.FailNow()
+	}
+	if msgs, _ := v.Run(); len(msgs["age"]) == 0 {
+		fmt.Println("expected Validate to leave the Validator's request, got", msgs)
+		t.FailNow()
+	}
+
+	strict := &Validator{Rules: []Rule{{Param: "age", Check: Integer}}, Strict: true}
+	if msgs, _ := strict.Validate(context.Background(), map[string]string{"age": "1", "admin": "1"}); len(msgs["admin"]) == 0 {
+		fmt.Println("expected an error for an unknown field, got", msgs)
+		t.FailNow()
+	}
+
+	var got string
+	admins := ValueSourceFunc(func(r *http.Request) ([]string, error) {
+		got = r.Form.Get("role")
+		return []string{"admin"}, nil
+	})
+	custom := func(r *http.Request, param string, _ Options) error {
+		if r.Form.Get(param) != "admin" {
+			return errors.New(param + " must be admin")
+		}
+		return nil
+	}
+
+	msgs, err := Validate(context.Background(), map[string]string{"role": "admin"},
+		Rule{Param: "role", Check: In, Options: Options{"values": admins}},
+		Rule{Param: "role", Check: custom},
+	)
+	if err != nil || len(msgs) > 0 || got != "admin" {
+		fmt.Println("expected the data to reach the ValueSource and CheckFunc, got", msgs, err, got)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,264 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultMaxMemory is the number of bytes of a multipart/form-data
+// request that a Validator stores in memory when its MaxMemory is 0.
+// It matches the default used by net/http.
+const DefaultMaxMemory int64 = 32 << 20
+
+// parseForm parses the request's form, or its multipart form so that
+// uploaded files can be validated, if it has not been parsed yet.
+func (v *Validator) parseForm() error {
+	r := v.request
+	if r.MultipartForm != nil || r.Form != nil && !isMultipart(r) {
+		return nil
+	}
+	if r.Body == nil {
+		// Server requests always have a body, so treat a missing
+		// one, as in a test, as empty rather than malformed.
+		r.Body = http.NoBody
+	}
+
+	var err error
+	if isMultipart(r) {
+		max := v.MaxMemory
+		if max <= 0 {
+			max = DefaultMaxMemory
+		}
+		err = r.ParseMultipartForm(max)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil && !isQueryError(err) {
+		return fmt.Errorf("unable to parse the request's form: %w", err)
+	}
+
+	return nil
+}
+
+// isQueryError reports whether err is from url.ParseQuery, such as
+// an invalid escape in `?x=%zz&y=1`. The values that could be parsed
+// are still in the Form, so they are validated rather than failing
+// the request, as they were before the form was parsed by Run.
+func isQueryError(err error) bool {
+	var escape url.EscapeError
+	return errors.As(err, &escape) || strings.Contains(err.Error(), "invalid semicolon separator in query")
+}
+
+// isMultipart reports whether the request body is multipart form data.
+func isMultipart(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// files returns the files uploaded for the parameter.
+func files(r *http.Request, param string) []*multipart.FileHeader {
+	if r.MultipartForm == nil {
+		return nil
+	}
+
+	return r.MultipartForm.File[param]
+}
+
+// sniff detects the MIME type of an uploaded file from its content,
+// ignoring the Content-Type sent by the client.
+func sniff(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	return mediaType, err
+}
+
+// formatSize formats a number of bytes for an error message.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30 && n%(1<<30) == 0:
+		return fmt.Sprintf("%dGB", n>>30)
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	}
+
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// sizeOption reads a number of bytes from the Options map, which can
+// be an int or an int64.
+func sizeOption(o Options, key string) (int64, bool) {
+	switch n := o[key].(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+
+	return 0, false
+}
+
+// RequiredFile returns an error if no file was uploaded for the
+// parameter. The other file rules ignore parameters without files,
+// so should be combined with RequiredFile if a file must be sent.
+var RequiredFile CheckFunc = func(r *http.Request, param string, o Options) error {
+	return requiredFileField(r.Context(), requestField(r, param, o))
+}
+
+func requiredFileField(ctx context.Context, f Field) error {
+	if len(f.Files) == 0 {
+		return fmt.Errorf("%s is required", f.Name)
+	}
+
+	return nil
+}
+
+// MaxFileSize returns an error if any file uploaded for the
+// parameter is larger than the `size` in bytes in the Options map.
+var MaxFileSize CheckFunc = func(r *http.Request, param string, o Options) error {
+	return maxFileSizeField(r.Context(), requestField(r, param, o))
+}
+
+func maxFileSizeField(ctx context.Context, f Field) error {
+	max, ok := sizeOption(f.Options, "size")
+	if !ok {
+		return misconfigured(f.Name, "size must be an int or int64")
+	}
+
+	for _, fh := range f.Files {
+		if fh.Size > max {
+			return fmt.Errorf("%s cannot be larger than %s", f.Name, formatSize(max))
+		}
+	}
+
+	return nil
+}
+
+// MinFileSize returns an error if any file uploaded for the
+// parameter is smaller than the `size` in bytes in the Options map.
+var MinFileSize CheckFunc = func(r *http.Request, param string, o Options) error {
+	return minFileSizeField(r.Context(), requestField(r, param, o))
+}
+
+func minFileSizeField(ctx context.Context, f Field) error {
+	min, ok := sizeOption(f.Options, "size")
+	if !ok {
+		return misconfigured(f.Name, "size must be an int or int64")
+	}
+
+	for _, fh := range f.Files {
+		if fh.Size < min {
+			return fmt.Errorf("%s must be at least %s", f.Name, formatSize(min))
+		}
+	}
+
+	return nil
+}
+
+// MaxFiles returns an error if more files were uploaded for the
+// parameter than the `count` in the Options map.
+var MaxFiles CheckFunc = func(r *http.Request, param string, o Options) error {
+	return maxFilesField(r.Context(), requestField(r, param, o))
+}
+
+func maxFilesField(ctx context.Context, f Field) error {
+	max, ok := f.Options["count"].(int)
+	if !ok {
+		return misconfigured(f.Name, "count must be an int")
+	}
+
+	if len(f.Files) > max {
+		return fmt.Errorf("%s cannot contain more than %d files", f.Name, max)
+	}
+
+	return nil
+}
+
+// FileExtension returns an error if any file uploaded for the
+// parameter does not have one of the `extensions` in the Options
+// map. Extensions are compared case-insensitively, with or without
+// a leading dot.
+var FileExtension CheckFunc = func(r *http.Request, param string, o Options) error {
+	return fileExtensionField(r.Context(), requestField(r, param, o))
+}
+
+func fileExtensionField(ctx context.Context, f Field) error {
+	extensions, ok := f.Options["extensions"].([]string)
+	if !ok {
+		return misconfigured(f.Name, "extensions must be a []string")
+	}
+
+	for _, fh := range f.Files {
+		ext := strings.TrimPrefix(filepath.Ext(fh.Filename), ".")
+
+		allowed := false
+		for _, e := range extensions {
+			if strings.EqualFold(strings.TrimPrefix(e, "."), ext) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return fmt.Errorf("%s must be a file of type: %s", f.Name, strings.Join(extensions, ", "))
+		}
+	}
+
+	return nil
+}
+
+// MimeType returns an error if any file uploaded for the parameter
+// is not one of the `types` in the Options map, such as "image/png"
+// or "image/*". The type is detected from the file's content with
+// http.DetectContentType, rather than trusting the client.
+var MimeType CheckFunc = func(r *http.Request, param string, o Options) error {
+	return mimeTypeField(r.Context(), requestField(r, param, o))
+}
+
+func mimeTypeField(ctx context.Context, f Field) error {
+	types, ok := f.Options["types"].([]string)
+	if !ok {
+		return misconfigured(f.Name, "types must be a []string")
+	}
+
+	for _, fh := range f.Files {
+		detected, err := sniff(fh)
+		if err != nil {
+			return fmt.Errorf("unable to read %s", f.Name)
+		}
+
+		allowed := false
+		for _, t := range types {
+			if t == detected || (strings.HasSuffix(t, "/*") && strings.HasPrefix(detected, t[:len(t)-1])) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return fmt.Errorf("%s must be a file of type: %s", f.Name, strings.Join(types, ", "))
+		}
+	}
+
+	return nil
+}
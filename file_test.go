@@ -0,0 +1,175 @@
+package validate
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// upload is a file to send in a multipart test request.
+type upload struct {
+	Param    string
+	Filename string
+	Content  []byte
+}
+
+// uploadRequest builds a multipart/form-data request containing the
+// given files.
+func uploadRequest(uploads ...upload) *http.Request {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+
+	for _, u := range uploads {
+		// Always claim to be a PNG, so tests can check that
+		// the content is sniffed instead.
+		h := make(map[string][]string)
+		h["Content-Disposition"] = []string{fmt.Sprintf(`form-data; name="%s"; filename="%s"`, u.Param, u.Filename)}
+		h["Content-Type"] = []string{"image/png"}
+		part, _ := w.CreatePart(h)
+		part.Write(u.Content)
+	}
+	w.WriteField("name", "tom")
+	w.Close()
+
+	r, _ := http.NewRequest("POST", "localhost", body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	return r
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+func TestRunParsesMultipartForms(t *testing.T) {
+	r := uploadRequest(upload{"avatar", "me.png", pngHeader})
+
+	if _, err := Check(r, Rule{Param: "name", Check: Required}); err != nil {
+		fmt.Println(err)
+		t.FailNow()
+	}
+
+	if r.Form.Get("name") != "tom" {
+		fmt.Println("expected the multipart form values to be parsed")
+		t.FailNow()
+	}
+
+	if len(files(r, "avatar")) != 1 {
+		fmt.Println("expected the multipart files to be parsed")
+		t.FailNow()
+	}
+}
+
+func TestFileRules(t *testing.T) {
+	text := []byte("just some text")
+	large := []byte(strings.Repeat("a", 2048))
+
+	rules := []struct {
+		Check   CheckFunc
+		Passes  [][]upload
+		Fails   [][]upload
+		Options Options
+	}{
+		{
+			RequiredFile,
+			[][]upload{{{"file", "a.txt", text}}},
+			[][]upload{{}, {{"other", "a.txt", text}}},
+			nil,
+		},
+		{
+			MaxFileSize,
+			[][]upload{{}, {{"file", "a.txt", text}}, {{"file", "a.txt", large[:1024]}}},
+			[][]upload{{{"file", "a.txt", text}, {"file", "b.txt", large}}},
+			Options{"size": 1 << 10},
+		},
+		{
+			MinFileSize,
+			[][]upload{{{"file", "a.txt", large}}},
+			[][]upload{{{"file", "a.txt", text}}},
+			Options{"size": int64(1 << 10)},
+		},
+		{
+			MaxFiles,
+			[][]upload{{}, {{"file", "a.txt", text}, {"file", "b.txt", text}}},
+			[][]upload{{{"file", "a.txt", text}, {"file", "b.txt", text}, {"file", "c.txt", text}}},
+			Options{"count": 2},
+		},
+		{
+			FileExtension,
+			[][]upload{{{"file", "a.txt", text}}, {{"file", "B.MD", text}}},
+			[][]upload{{{"file", "a.exe", text}}, {{"file", "txt", text}}, {{"file", "a.txt.exe", text}}},
+			Options{"extensions": []string{"txt", ".md"}},
+		},
+		{
+			MimeType,
+			[][]upload{{{"file", "a.png", pngHeader}}},
+			[][]upload{{{"file", "a.png", text}}},
+			Options{"types": []string{"image/*"}},
+		},
+		{
+			MimeType,
+			[][]upload{{{"file", "a.png", text}}, {{"file", "a.png", pngHeader}}},
+			[][]upload{{{"file", "a.pdf", []byte("%PDF-1.7")}}},
+			Options{"types": []string{"text/plain", "image/png"}},
+		},
+	}
+
+	for _, rule := range rules {
+		for _, u := range rule.Passes {
+			msgs, _ := Check(uploadRequest(u...), Rule{Param: "file", Check: rule.Check, Options: rule.Options})
+			if len(msgs) > 0 {
+				fmt.Println("Got an error, expected none:", msgs["file"])
+				fmt.Println("Uploads were", u)
+				t.FailNow()
+			}
+		}
+
+		for _, u := range rule.Fails {
+			msgs, _ := Check(uploadRequest(u...), Rule{Param: "file", Check: rule.Check, Options: rule.Options})
+			if len(msgs) == 0 {
+				fmt.Println("Expected an error, didn't get one")
+				fmt.Println("Uploads were", u)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestMultipartFormUsesMaxMemory(t *testing.T) {
+	r := uploadRequest(upload{"file", "a.txt", []byte(strings.Repeat("a", 1024))})
+	v := Make(r, Rule{Param: "file", Check: MaxFileSize, Options: Options{"size": 1 << 10}})
+	v.MaxMemory = 16
+	msgs, _ := v.Run()
+	if len(msgs) > 0 {
+		fmt.Println("expected files stored on disk to be validated, got", msgs)
+		t.FailNow()
+	}
+	r.MultipartForm.RemoveAll()
+}
+
+func TestRunReturnsFormErrors(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", strings.NewReader("not multipart"))
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=xyz")
+
+	if _, err := Check(r, Rule{Param: "file", Check: Required}); err == nil || !strings.HasPrefix(err.Error(), "unable to parse the request's form") {
+		fmt.Println("expected the form error, got", err)
+		t.FailNow()
+	}
+}
+
+func TestRunValidatesPartiallyParsedQueries(t *testing.T) {
+	r, _ := http.NewRequest("GET", "localhost?x=%zz&y=1", nil)
+
+	msgs, err := Check(r, Rule{Param: "y", Check: Integer})
+	if err != nil || len(msgs) > 0 {
+		fmt.Println("expected the parsed values to be validated, got", msgs, err)
+		t.FailNow()
+	}
+
+	r, _ = http.NewRequest("POST", "localhost", strings.NewReader("name=%zz&age=x"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if msgs, err := Check(r, Rule{Param: "age", Check: Integer}); err != ValidationFailed || len(msgs["age"]) == 0 {
+		fmt.Println("expected the body's parsed values to be validated, got", msgs, err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,89 @@
+package validate
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Filter transforms a parameter's value before its rules are
+// checked. Any func(string) string can be used as a Filter.
+type Filter func(string) string
+
+// Trim removes leading and trailing white space.
+var Trim Filter = strings.TrimSpace
+
+// Lower maps the value to lower case.
+var Lower Filter = strings.ToLower
+
+// Upper maps the value to upper case.
+var Upper Filter = strings.ToUpper
+
+// CollapseSpaces trims the value and replaces each run of white
+// space inside it with a single space.
+var CollapseSpaces Filter = func(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// StripSpaces removes all white space from the value, which is
+// useful for values such as phone numbers and card numbers.
+var StripSpaces Filter = func(s string) string {
+	return strings.Map(func(c rune) rune {
+		if unicode.IsSpace(c) {
+			return -1
+		}
+		return c
+	}, s)
+}
+
+// StripTags removes anything that looks like an HTML tag or comment
+// from the value. It does not make a value safe to use as HTML, so
+// values should still be escaped when they are output.
+var StripTags Filter = func(s string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '<')
+		if i < 0 || i == len(s)-1 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+
+		// A `<` that does not start a tag, such as in `1 < 2`, is kept.
+		if c := s[1]; !(c == '/' || c == '!' || c == '?' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			b.WriteByte('<')
+			s = s[1:]
+			continue
+		}
+
+		end := ">"
+		if strings.HasPrefix(s, "<!--") {
+			end = "-->"
+		}
+		j := strings.Index(s, end)
+		if j < 0 {
+			return b.String()
+		}
+		s = s[j+len(end):]
+	}
+}
+
+// NormalizeUnicode converts the value to Unicode Normalization Form C,
+// so that visually identical values, such as an `é` typed as one code
+// point or as an `e` followed by a combining accent, compare equal.
+var NormalizeUnicode Filter = norm.NFC.String
+
+// filter applies each of the filters to each of the values in turn.
+func filter(values []string, filters []Filter) []string {
+	filtered := make([]string, len(values))
+	for i, value := range values {
+		for _, f := range filters {
+			value = f(value)
+		}
+		filtered[i] = value
+	}
+
+	return filtered
+}
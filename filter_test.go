@@ -0,0 +1,122 @@
+package validate
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	filters := []struct {
+		Filter Filter
+		Value  string
+		Want   string
+	}{
+		{Trim, "  me@tomm.us\n", "me@tomm.us"},
+		{Lower, "Me@Tomm.US", "me@tomm.us"},
+		{Upper, "sw1a 1aa", "SW1A 1AA"},
+		{CollapseSpaces, "  John \t  Smith ", "John Smith"},
+		{StripSpaces, "+44 7400 123 456", "+447400123456"},
+		{StripTags, "<b>bold</b> text", "bold text"},
+		{StripTags, "<a href=\"x\">link</a><!-- <b>comment</b> -->", "link"},
+		{StripTags, "1 < 2 and 3 > 2", "1 < 2 and 3 > 2"},
+		{StripTags, "unclosed <script", "unclosed "},
+		{NormalizeUnicode, "Cafe\u0301", "Caf\u00e9"},
+		{NormalizeUnicode, "\u1100\u1161\u11a8", "\uac01"},
+		{NormalizeUnicode, "a\u0323\u0302", "\u1ead"},
+		{NormalizeUnicode, "a\u0302\u0323", "\u1ead"},
+		{NormalizeUnicode, "\u212b", "\u00c5"},
+		{NormalizeUnicode, "plain", "plain"},
+	}
+
+	for _, f := range filters {
+		if got := f.Filter(f.Value); got != f.Want {
+			fmt.Printf("filtering %+q: got %+q, want %+q\n", f.Value, got, f.Want)
+			t.FailNow()
+		}
+	}
+}
+
+func TestFiltersRunBeforeChecks(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+	r.Form.Set("email", "  ME@Tomm.us ")
+	r.Form.Set("name", "  John   Smith ")
+
+	reverse := func(s string) string {
+		runes := []rune(s)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes)
+	}
+
+	v := Make(r,
+		Rule{Param: "email", Check: Email, Filters: []Filter{Trim, Lower}},
+		Rule{Param: "name", Check: MaxLength, Options: Options{"length": 10}, Filters: []Filter{CollapseSpaces, reverse}},
+	)
+
+	if msgs, _ := v.Run(); len(msgs) > 0 {
+		fmt.Println("expected the filtered values to pass, got", msgs)
+		t.FailNow()
+	}
+
+	if got := v.Filtered().Get("email"); got != "me@tomm.us" {
+		fmt.Println("unexpected filtered email:", got)
+		t.FailNow()
+	}
+
+	if got := v.Filtered().Get("name"); got != "htimS nhoJ" {
+		fmt.Println("unexpected filtered name:", got)
+		t.FailNow()
+	}
+
+	if got := r.Form.Get("email"); got != "  ME@Tomm.us " {
+		fmt.Println("expected the request to be unchanged, got", got)
+		t.FailNow()
+	}
+}
+
+func TestUpdateForm(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+	r.Form["tags"] = []string{" Go ", "HTTP"}
+
+	v := Make(r, Rule{Param: "tags", Check: Required, Filters: []Filter{Trim, Lower}})
+	v.UpdateForm = true
+	v.Run()
+
+	if got := strings.Join(r.Form["tags"], ","); got != "go,http" {
+		fmt.Println("expected the request's form to be updated, got", got)
+		t.FailNow()
+	}
+}
+
+func TestFiltersApplyToTheirRule(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+	r.Form.Set("name", " Jo ")
+
+	exclaim := func(s string) string {
+		return s + "!"
+	}
+
+	v := Make(r,
+		Rule{Param: "name", Check: MinLength, Options: Options{"length": 3}, Filters: []Filter{Trim}},
+		Rule{Param: "name", Check: MaxLength, Options: Options{"length": 4}},
+		Rule{Param: "name", Check: Required, Filters: []Filter{exclaim}},
+		Rule{Param: "name", Check: Required, Filters: []Filter{exclaim}},
+	)
+
+	msgs, _ := v.Run()
+	if len(msgs["name"]) != 1 || msgs["name"][0] != "name must be longer than 3 characters" {
+		fmt.Println("expected only the trimmed value to be too short, got", msgs)
+		t.FailNow()
+	}
+
+	if got := v.Filtered().Get("name"); got != " Jo !" {
+		fmt.Println("expected the filters not to compound, got", got)
+		t.FailNow()
+	}
+}
@@ -1,3 +1,9 @@
 module github.com/gostalt/validate
 
-go 1.12
+go 1.22
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	golang.org/x/text v0.22.0
+	gopkg.in/yaml.v3 v3.0.1
+)
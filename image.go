@@ -0,0 +1,162 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	// Registers the formats that the image rules can decode.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// imageConfig decodes the header of an uploaded image, without
+// decoding the image itself.
+func imageConfig(fh *multipart.FileHeader) (image.Config, string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return image.Config{}, "", err
+	}
+	defer f.Close()
+
+	return image.DecodeConfig(f)
+}
+
+// Image returns an error if any file uploaded for the parameter
+// is not a PNG, JPEG or GIF image. The allowed formats can be
+// restricted with a `formats` key in the Options map, such as
+// []string{"png", "jpeg"}.
+var Image CheckFunc = func(r *http.Request, param string, o Options) error {
+	return imageField(r.Context(), requestField(r, param, o))
+}
+
+func imageField(ctx context.Context, f Field) error {
+	formats, restricted := f.Options["formats"].([]string)
+
+	for _, fh := range f.Files {
+		_, format, err := imageConfig(fh)
+		if err != nil {
+			return fmt.Errorf("%s must be an image", f.Name)
+		}
+
+		if !restricted {
+			continue
+		}
+
+		allowed := false
+		for _, f := range formats {
+			if f == format || (f == "jpg" && format == "jpeg") {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return fmt.Errorf("%s must be an image of type: %s", f.Name, strings.Join(formats, ", "))
+		}
+	}
+
+	return nil
+}
+
+// ImageDimensions returns an error if any image uploaded for the
+// parameter falls outside the `min_width`, `max_width`, `min_height`
+// or `max_height` in pixels in the Options map. Only the bounds that
+// are passed are checked.
+var ImageDimensions CheckFunc = func(r *http.Request, param string, o Options) error {
+	return imageDimensionsField(r.Context(), requestField(r, param, o))
+}
+
+func imageDimensionsField(ctx context.Context, f Field) error {
+	for _, fh := range f.Files {
+		cfg, _, err := imageConfig(fh)
+		if err != nil {
+			return fmt.Errorf("%s must be an image", f.Name)
+		}
+
+		if min, ok := f.Options["min_width"].(int); ok && cfg.Width < min {
+			return fmt.Errorf("%s must be at least %d pixels wide", f.Name, min)
+		}
+
+		if max, ok := f.Options["max_width"].(int); ok && cfg.Width > max {
+			return fmt.Errorf("%s cannot be more than %d pixels wide", f.Name, max)
+		}
+
+		if min, ok := f.Options["min_height"].(int); ok && cfg.Height < min {
+			return fmt.Errorf("%s must be at least %d pixels high", f.Name, min)
+		}
+
+		if max, ok := f.Options["max_height"].(int); ok && cfg.Height > max {
+			return fmt.Errorf("%s cannot be more than %d pixels high", f.Name, max)
+		}
+	}
+
+	return nil
+}
+
+// AspectRatio returns an error if any image uploaded for the
+// parameter does not have the `ratio` in the Options map. The ratio
+// is either a width:height string such as "16:9" or a float64 of
+// width divided by height. A `tolerance` float64 can be passed to
+// allow for rounding, and defaults to 0.01.
+var AspectRatio CheckFunc = func(r *http.Request, param string, o Options) error {
+	return aspectRatioField(r.Context(), requestField(r, param, o))
+}
+
+func aspectRatioField(ctx context.Context, f Field) error {
+	ratio, ok := parseRatio(f.Options["ratio"])
+	if !ok {
+		return misconfigured(f.Name, "ratio must be a width:height string or a float64")
+	}
+
+	tolerance, ok := f.Options["tolerance"].(float64)
+	if !ok {
+		tolerance = 0.01
+	}
+
+	for _, fh := range f.Files {
+		cfg, _, err := imageConfig(fh)
+		if err != nil || cfg.Height == 0 {
+			return fmt.Errorf("%s must be an image", f.Name)
+		}
+
+		actual := float64(cfg.Width) / float64(cfg.Height)
+		if math.Abs(actual-ratio) > tolerance {
+			return fmt.Errorf("%s must have an aspect ratio of %v", f.Name, f.Options["ratio"])
+		}
+	}
+
+	return nil
+}
+
+func parseRatio(v interface{}) (float64, bool) {
+	switch ratio := v.(type) {
+	case float64:
+		return ratio, ratio > 0
+	case string:
+		parts := strings.Split(ratio, ":")
+		if len(parts) != 2 {
+			return 0, false
+		}
+
+		w, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return 0, false
+		}
+
+		h, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || h <= 0 || w <= 0 {
+			return 0, false
+		}
+
+		return w / h, true
+	}
+
+	return 0, false
+}
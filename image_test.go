@@ -0,0 +1,98 @@
+package validate
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// encodeImage returns a blank image of the given size and format.
+func encodeImage(format string, width int, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	buf := &bytes.Buffer{}
+
+	switch format {
+	case "png":
+		png.Encode(buf, img)
+	case "jpeg":
+		jpeg.Encode(buf, img, nil)
+	case "gif":
+		gif.Encode(buf, img, nil)
+	}
+
+	return buf.Bytes()
+}
+
+func TestImageRules(t *testing.T) {
+	square := encodeImage("png", 100, 100)
+	wide := encodeImage("jpeg", 160, 90)
+	animated := encodeImage("gif", 40, 30)
+
+	rules := []struct {
+		Check   CheckFunc
+		Passes  [][]byte
+		Fails   [][]byte
+		Options Options
+	}{
+		{
+			Image,
+			[][]byte{square, wide, animated},
+			[][]byte{[]byte("not an image"), pngHeader},
+			nil,
+		},
+		{
+			Image,
+			[][]byte{square, wide},
+			[][]byte{animated},
+			Options{"formats": []string{"png", "jpg"}},
+		},
+		{
+			ImageDimensions,
+			[][]byte{square, wide},
+			[][]byte{animated, encodeImage("png", 300, 100)},
+			Options{"min_width": 50, "max_width": 200, "min_height": 50},
+		},
+		{
+			ImageDimensions,
+			[][]byte{square},
+			[][]byte{encodeImage("png", 100, 101)},
+			Options{"max_height": 100},
+		},
+		{
+			AspectRatio,
+			[][]byte{wide, encodeImage("png", 1920, 1080)},
+			[][]byte{square, animated},
+			Options{"ratio": "16:9"},
+		},
+		{
+			AspectRatio,
+			[][]byte{square, encodeImage("png", 100, 99)},
+			[][]byte{wide},
+			Options{"ratio": 1.0, "tolerance": 0.02},
+		},
+	}
+
+	for _, rule := range rules {
+		for _, content := range rule.Passes {
+			r := uploadRequest(upload{"image", "image", content})
+			msgs, _ := Check(r, Rule{Param: "image", Check: rule.Check, Options: rule.Options})
+			if len(msgs) > 0 {
+				fmt.Println("Got an error, expected none:", msgs["image"])
+				t.FailNow()
+			}
+		}
+
+		for _, content := range rule.Fails {
+			r := uploadRequest(upload{"image", "image", content})
+			msgs, _ := Check(r, Rule{Param: "image", Check: rule.Check, Options: rule.Options})
+			if len(msgs) == 0 {
+				fmt.Println("Expected an error, didn't get one")
+				t.FailNow()
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ValueSource provides the allowed values for In and NotIn when
+// they are only known at the time the request is validated, such
+// as the IDs of the projects the current user can access. Values is
+// given the request being validated, so that it can read any part
+// of it, rather than only the values of the Rule's Source.
+type ValueSource interface {
+	Values(r *http.Request) ([]string, error)
+}
+
+// ValueSourceFunc is a function that can be used as a ValueSource.
+type ValueSourceFunc func(r *http.Request) ([]string, error)
+
+// Values calls f(r).
+func (f ValueSourceFunc) Values(r *http.Request) ([]string, error) {
+	return f(r)
+}
+
+// Stringers returns a ValueSource made up of the String values of
+// each argument. It is useful for enum types that implement
+// fmt.Stringer.
+func Stringers(values ...fmt.Stringer) ValueSource {
+	return ValueSourceFunc(func(*http.Request) ([]string, error) {
+		s := make([]string, len(values))
+		for i, v := range values {
+			s[i] = v.String()
+		}
+
+		return s, nil
+	})
+}
+
+// In returns an error if the parameter is not one of the `values`
+// in the Options map. The values are either a slice of strings or
+// a ValueSource, which is called each time the rule is checked. If
+// the ValueSource fails, Run returns its error in a *CheckError.
+var In CheckFunc = func(r *http.Request, param string, o Options) error {
+	return inField(r.Context(), requestField(r, param, o))
+}
+
+func inField(ctx context.Context, f Field) error {
+	return checkIn(ctx, f, false, false)
+}
+
+// NotIn returns an error if the parameter is one of the `values`
+// in the Options map.
+var NotIn CheckFunc = func(r *http.Request, param string, o Options) error {
+	return notInField(r.Context(), requestField(r, param, o))
+}
+
+func notInField(ctx context.Context, f Field) error {
+	return checkIn(ctx, f, true, false)
+}
+
+// InFold is like In, but compares values case-insensitively.
+var InFold CheckFunc = func(r *http.Request, param string, o Options) error {
+	return inFoldField(r.Context(), requestField(r, param, o))
+}
+
+func inFoldField(ctx context.Context, f Field) error {
+	return checkIn(ctx, f, false, true)
+}
+
+// NotInFold is like NotIn, but compares values case-insensitively.
+var NotInFold CheckFunc = func(r *http.Request, param string, o Options) error {
+	return notInFoldField(r.Context(), requestField(r, param, o))
+}
+
+func notInFoldField(ctx context.Context, f Field) error {
+	return checkIn(ctx, f, true, true)
+}
+
+func checkIn(ctx context.Context, f Field, negate bool, fold bool) error {
+	param, value := f.Name, f.String()
+
+	var values []string
+	static := false
+
+	switch v := f.Options["values"].(type) {
+	case []string:
+		values = v
+		static = true
+	case ValueSource:
+		var err error
+		if values, err = v.Values(originalRequest(ctx, f.request)); err != nil {
+			return &CheckError{Param: param, Err: fmt.Errorf("unable to load allowed values: %w", err)}
+		}
+	default:
+		return misconfigured(param, "values must be a []string or ValueSource")
+	}
+
+	found := false
+	for _, allowed := range values {
+		if allowed == value || (fold && strings.EqualFold(allowed, value)) {
+			found = true
+			break
+		}
+	}
+
+	switch {
+	case negate && found:
+		return fmt.Errorf("%s must not be %s", param, value)
+	case !negate && !found && static:
+		return fmt.Errorf("%s must be one of: %s", param, strings.Join(values, ", "))
+	case !negate && !found:
+		return fmt.Errorf("%s is not an allowed value", param)
+	}
+
+	return nil
+}
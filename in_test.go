@@ -0,0 +1,122 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type colour int
+
+const (
+	red colour = iota
+	green
+	blue
+)
+
+func (c colour) String() string {
+	return [...]string{"red", "green", "blue"}[c]
+}
+
+func TestInRules(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+
+	projects := ValueSourceFunc(func(r *http.Request) ([]string, error) {
+		return []string{r.Form.Get("user") + "-1", r.Form.Get("user") + "-2"}, nil
+	})
+	r.Form.Set("user", "tom")
+
+	rules := []struct {
+		Check   CheckFunc
+		Passes  []string
+		Fails   []string
+		Options Options
+	}{
+		{
+			In,
+			[]string{"draft", "published"},
+			[]string{"", "Draft", "deleted"},
+			Options{"values": []string{"draft", "published"}},
+		},
+		{
+			NotIn,
+			[]string{"tom", "Admin"},
+			[]string{"admin", "root"},
+			Options{"values": []string{"admin", "root"}},
+		},
+		{
+			InFold,
+			[]string{"DRAFT", "Published"},
+			[]string{"deleted"},
+			Options{"values": []string{"draft", "published"}},
+		},
+		{
+			NotInFold,
+			[]string{"tom"},
+			[]string{"ADMIN", "Root"},
+			Options{"values": []string{"admin", "root"}},
+		},
+		{
+			In,
+			[]string{"tom-1", "tom-2"},
+			[]string{"lucy-1", "tom-3"},
+			Options{"values": projects},
+		},
+		{
+			In,
+			[]string{"red", "blue"},
+			[]string{"purple", "0"},
+			Options{"values": Stringers(red, green, blue)},
+		},
+	}
+
+	for _, rule := range rules {
+		for _, value := range rule.Passes {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: rule.Check, Options: rule.Options})
+			if len(msgs) > 0 {
+				fmt.Println("Got an error, expected none:", msgs["parameter"])
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+
+		for _, value := range rule.Fails {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: rule.Check, Options: rule.Options})
+			if len(msgs) == 0 {
+				fmt.Println("Expected an error, didn't get one")
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestInRulesReturnValueSourceErrors(t *testing.T) {
+	r, _ := http.NewRequest("GET", "localhost?project=1", nil)
+	unavailable := errors.New("database unavailable")
+	failing := ValueSourceFunc(func(*http.Request) ([]string, error) {
+		return nil, unavailable
+	})
+
+	msgs, err := Make(r, Rule{Param: "project", Check: In, Options: Options{"values": failing}}).Run()
+	if len(msgs) > 0 || !errors.Is(err, ErrCheckFailed) || !errors.Is(err, unavailable) {
+		fmt.Println("expected the ValueSource's error to be returned, got", msgs, err)
+		t.FailNow()
+	}
+
+	if _, err := Make(r, Rule{Param: "project", Check: In}).Run(); !errors.Is(err, ErrMisconfiguredRule) {
+		fmt.Println("expected In without values to be misconfigured, got", err)
+		t.FailNow()
+	}
+
+	msgs, err = Validate(context.Background(), map[string]string{"project": "1"}, Rule{Param: "project", Check: NotIn, Options: Options{"values": failing}})
+	if len(msgs) > 0 || !errors.Is(err, ErrCheckFailed) {
+		fmt.Println("expected Validate to return the ValueSource's error, got", msgs, err)
+		t.FailNow()
+	}
+}
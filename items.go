@@ -0,0 +1,171 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// itemName is the name that the value at index i of a parameter is
+// checked and reported as.
+func itemName(param string, i int) string {
+	return param + "." + strconv.Itoa(i)
+}
+
+// mergeLists adds the values of bracketed list parameters, such as
+// `tags[]`, to the rules' parameters, and returns the keys of the
+// rules whose parameters were given as a list. The listed names are
+// the fields of the data passed to Validate that were slices.
+func (v *Validator) mergeLists(sources map[Source]url.Values, listed map[string]bool) map[string]bool {
+	lists := make(map[string]bool)
+	for _, rule := range v.Rules {
+		form := sources[rule.Source]
+		if values, ok := form[rule.Param+"[]"]; ok && !strings.HasSuffix(rule.Param, "[]") {
+			form[rule.Param] = append(form[rule.Param], values...)
+			delete(form, rule.Param+"[]")
+			lists[rule.key()] = true
+		}
+
+		if len(form[rule.Param]) > 1 {
+			lists[rule.key()] = true
+		}
+
+		if rule.Source == Form && listed[rule.Param] {
+			lists[rule.key()] = true
+		}
+	}
+
+	return lists
+}
+
+// List returns an error if the parameter was not given as a list.
+// A parameter is a list if it is sent more than once, such as
+// `?tag=a&tag=b`, if its name has a `[]` suffix, such as `tag[]=a`,
+// or if it is a slice in the data passed to Validate. It can be
+// combined with MinItems to require a list with at least one value.
+var List CheckFunc = func(r *http.Request, param string, o Options) error {
+	return listField(r.Context(), requestField(r, param, o))
+}
+
+func listField(ctx context.Context, f Field) error {
+	if rr := runFor(ctx); rr != nil && rr.lists[Rule{Param: f.Name, Source: rr.source}.key()] {
+		return nil
+	}
+
+	if len(f.Values) > 1 {
+		return nil
+	}
+
+	return fmt.Errorf("%s must be a list", f.Name)
+}
+
+// MinItems returns an error if the parameter has fewer values than
+// the `count` in the Options map.
+var MinItems CheckFunc = func(r *http.Request, param string, o Options) error {
+	return minItemsField(r.Context(), requestField(r, param, o))
+}
+
+func minItemsField(ctx context.Context, f Field) error {
+	count, ok := f.Options["count"].(int)
+	if !ok {
+		return misconfigured(f.Name, "count must be an int")
+	}
+
+	if len(f.Values) < count {
+		return fmt.Errorf("%s must have at least %d items", f.Name, count)
+	}
+
+	return nil
+}
+
+// MaxItems returns an error if the parameter has more values than
+// the `count` in the Options map.
+var MaxItems CheckFunc = func(r *http.Request, param string, o Options) error {
+	return maxItemsField(r.Context(), requestField(r, param, o))
+}
+
+func maxItemsField(ctx context.Context, f Field) error {
+	count, ok := f.Options["count"].(int)
+	if !ok {
+		return misconfigured(f.Name, "count must be an int")
+	}
+
+	if len(f.Values) > count {
+		return fmt.Errorf("%s must have no more than %d items", f.Name, count)
+	}
+
+	return nil
+}
+
+// Distinct returns an error for each of the parameter's values that
+// repeats an earlier one. Passing `"fold": true` in the Options map
+// ignores case when comparing values.
+var Distinct CheckFunc = func(r *http.Request, param string, o Options) error {
+	return distinctField(r.Context(), requestField(r, param, o))
+}
+
+func distinctField(ctx context.Context, f Field) error {
+	fold, _ := f.Options["fold"].(bool)
+
+	errs := make(ItemErrors)
+	seen := make(map[string]bool)
+	for i, value := range f.Values {
+		if fold {
+			value = strings.ToLower(value)
+		}
+		if seen[value] {
+			errs[i] = fmt.Errorf("%s must not be a duplicate value", itemName(f.Name, i))
+		}
+		seen[value] = true
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Each returns a CheckFunc that runs check against each of the
+// parameter's values in turn, with the Rule's Options. Each value is
+// checked, and reported, as the parameter's name followed by its
+// index, such as `tags.0`.
+func Each(check CheckFunc) CheckFunc {
+	return func(r *http.Request, param string, o Options) error {
+		values := r.Form[param]
+
+		form := make(url.Values, len(r.Form)+1)
+		for name, vs := range r.Form {
+			form[name] = vs
+		}
+		ir := r.WithContext(r.Context())
+		ir.Form = form
+
+		errs := make(ItemErrors)
+		for i, value := range values {
+			name := itemName(param, i)
+			form[name] = []string{value}
+			err := check(ir, name, o)
+			if errors.Is(err, ErrMisconfiguredRule) {
+				return err
+			} else if err != nil {
+				errs[i] = err
+			}
+			if vs, ok := r.Form[name]; ok {
+				form[name] = vs
+			} else {
+				delete(form, name)
+			}
+		}
+
+		if len(errs) > 0 {
+			return errs
+		}
+
+		return nil
+	}
+}
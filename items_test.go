@@ -0,0 +1,81 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestItems(t *testing.T) {
+	r, _ := http.NewRequest("GET", "localhost?tag=go&tag=http&tag=Go&tag=x1&single=a", nil)
+
+	rules := []struct {
+		Rule   Rule
+		Errors []string
+	}{
+		{Rule{Param: "tag", Check: List}, nil},
+		{Rule{Param: "single", Check: List}, []string{"single"}},
+		{Rule{Param: "tag", Check: MinItems, Options: Options{"count": 4}}, nil},
+		{Rule{Param: "tag", Check: MinItems, Options: Options{"count": 5}}, []string{"tag"}},
+		{Rule{Param: "tag", Check: MaxItems, Options: Options{"count": 4}}, nil},
+		{Rule{Param: "tag", Check: MaxItems, Options: Options{"count": 3}}, []string{"tag"}},
+		{Rule{Param: "tag", Check: Distinct}, nil},
+		{Rule{Param: "tag", Check: Distinct, Options: Options{"fold": true}}, []string{"tag.2"}},
+		{Rule{Param: "tag", Check: Each(Alpha)}, []string{"tag.3"}},
+		{Rule{Param: "tag", Check: Each(MaxLength), Options: Options{"length": 2}}, []string{"tag.1"}},
+		{Rule{Param: "missing", Check: Each(Integer)}, nil},
+	}
+
+	for _, rule := range rules {
+		msgs, _ := Check(r, rule.Rule)
+
+		var keys []string
+		for key := range msgs {
+			keys = append(keys, key)
+		}
+
+		if !reflect.DeepEqual(keys, rule.Errors) {
+			fmt.Println("expected errors for", rule.Errors, "got", msgs)
+			t.FailNow()
+		}
+	}
+}
+
+func TestEachReportsIndexes(t *testing.T) {
+	r, _ := http.NewRequest("GET", "localhost?id[]=1&id[]=two&id[]=3&id[]=four", nil)
+
+	v := Make(r, Rule{Param: "id", Source: Query, Check: Each(Integer)})
+	msgs, _ := v.Run()
+
+	want := Message{
+		"query.id.1": {"id.1 must be an integer"},
+		"query.id.3": {"id.3 must be an integer"},
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		fmt.Println("unexpected errors:", msgs)
+		t.FailNow()
+	}
+}
+
+func TestListValues(t *testing.T) {
+	r, _ := http.NewRequest("GET", "localhost?colour[]=red", nil)
+
+	v := Make(r, Rule{Param: "colour", Check: List}, Rule{Param: "colour", Check: MinItems, Options: Options{"count": 1}})
+	if msgs, _ := v.Run(); len(msgs) > 0 {
+		fmt.Println("expected a bracketed parameter to be a list, got", msgs)
+		t.FailNow()
+	}
+
+	if got := v.Validated()["colour"]; !reflect.DeepEqual(got, []string{"red"}) {
+		fmt.Println("expected the list's values, got", got)
+		t.FailNow()
+	}
+
+	msgs, _ := Validate(context.Background(), map[string]interface{}{"ids": []int{7}}, Rule{Param: "ids", Check: List})
+	if len(msgs) > 0 {
+		fmt.Println("expected a slice to be a list, got", msgs)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,1080 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSONSchema is a JSON Schema (draft 2020-12) loaded from local
+// files, which can check request bodies and other values.
+//
+// The `$ref`, `allOf`, `anyOf`, `oneOf`, `not`, `if`/`then`/`else`,
+// `type`, `enum`, `const`, `format` and `required` keywords are
+// supported, along with the string, number, object and array
+// keywords such as `maxLength`, `minimum`, `properties`,
+// `additionalProperties`, `items` and `uniqueItems`. References can
+// point into the same document, or to other local files relative to
+// the document, but not to URLs. Unknown keywords and formats are
+// ignored, as the specification allows.
+//
+// Files whose names end in `.yaml` or `.yml` are read as YAML, and
+// others as JSON. A schema that refers to a file that cannot be read,
+// or to a part of a document that does not exist, cannot be loaded,
+// and one with a `pattern` that does not compile returns a RuleError.
+type JSONSchema struct {
+	doc  *schemaDoc
+	node interface{}
+}
+
+// schemaDoc is a parsed JSON document that schemas are read from.
+type schemaDoc struct {
+	name   string
+	root   interface{}
+	loader *schemaLoader
+}
+
+// schemaLoader reads and caches the documents that schemas refer to.
+type schemaLoader struct {
+	read func(name string) ([]byte, error)
+	join func(base string, ref string) string
+
+	mu   sync.Mutex
+	docs map[string]*schemaDoc
+
+	patternMu sync.Mutex
+	patterns  map[string]*regexp.Regexp
+}
+
+func osLoader() *schemaLoader {
+	return &schemaLoader{
+		read: os.ReadFile,
+		join: func(base string, ref string) string {
+			return filepath.Join(filepath.Dir(base), filepath.FromSlash(ref))
+		},
+	}
+}
+
+func fsLoader(fsys fs.FS) *schemaLoader {
+	return &schemaLoader{
+		read: func(name string) ([]byte, error) { return fs.ReadFile(fsys, name) },
+		join: func(base string, ref string) string { return path.Join(path.Dir(base), ref) },
+	}
+}
+
+// LoadSchema reads a JSON Schema from a local file.
+func LoadSchema(name string) (*JSONSchema, error) {
+	return osLoader().schema(name)
+}
+
+// LoadSchemaFS reads a JSON Schema from a file in fsys, such as an
+// embed.FS. References to other files are read from fsys.
+func LoadSchemaFS(fsys fs.FS, name string) (*JSONSchema, error) {
+	return fsLoader(fsys).schema(name)
+}
+
+// ParseSchema parses a JSON Schema. References to other files are
+// read relative to the working directory.
+func ParseSchema(data []byte) (*JSONSchema, error) {
+	l := osLoader()
+	doc, err := l.parse("schema.json", data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONSchema{doc, doc.root}, nil
+}
+
+func (l *schemaLoader) schema(name string) (*JSONSchema, error) {
+	doc, err := l.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONSchema{doc, doc.root}, nil
+}
+
+// load returns the named document, reading and parsing it if it has
+// not been loaded yet. The lock is held from the lookup until the
+// document is stored, so concurrent loads of a document read it
+// once. References are checked after it is released, as they can
+// load other documents, or this one again.
+func (l *schemaLoader) load(name string) (*schemaDoc, error) {
+	l.mu.Lock()
+	if doc, ok := l.docs[name]; ok {
+		l.mu.Unlock()
+		return doc, nil
+	}
+
+	data, err := l.read(name)
+	if err != nil {
+		l.mu.Unlock()
+		return nil, err
+	}
+
+	doc, err := l.add(name, data)
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.check(doc.root, ""); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// parse parses a document, and checks that all of the references in
+// it, and in the documents it refers to, can be resolved and that
+// their patterns compile.
+func (l *schemaLoader) parse(name string, data []byte) (*schemaDoc, error) {
+	l.mu.Lock()
+	doc, err := l.add(name, data)
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.check(doc.root, ""); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// add parses a document and stores it, so that references back to
+// it are resolved to the same document. The lock must be held.
+func (l *schemaLoader) add(name string, data []byte) (*schemaDoc, error) {
+	var root interface{}
+	var err error
+	if ext := strings.ToLower(path.Ext(name)); ext == ".yaml" || ext == ".yml" {
+		root, err = parseYAML(data)
+	} else {
+		err = json.Unmarshal(data, &root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", name, err)
+	}
+
+	doc := &schemaDoc{name: name, root: root, loader: l}
+	if l.docs == nil {
+		l.docs = make(map[string]*schemaDoc)
+	}
+	l.docs[name] = doc
+
+	return doc, nil
+}
+
+// pattern compiles a `pattern` or `patternProperties` key, caching it
+// for as long as the schemas read by the loader are in use.
+func (l *schemaLoader) pattern(p string) (*regexp.Regexp, error) {
+	l.patternMu.Lock()
+	defer l.patternMu.Unlock()
+
+	if re, ok := l.patterns[p]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.patterns == nil {
+		l.patterns = make(map[string]*regexp.Regexp)
+	}
+	l.patterns[p] = re
+	return re, nil
+}
+
+// check resolves each `$ref` inside node, and compiles each
+// `pattern` and `patternProperties` key. Values given by keywords
+// such as `const` and `default` are data rather than schemas, so they
+// are not checked. pointer is the JSON Pointer to node.
+func (d *schemaDoc) check(node interface{}, pointer string) error {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := node["$ref"].(string); ok {
+			if _, _, err := d.resolve(ref); err != nil {
+				return err
+			}
+		}
+		if p, ok := node["pattern"].(string); ok {
+			if err := d.checkPattern(p, pointer+"/pattern"); err != nil {
+				return err
+			}
+		}
+		if props, ok := node["patternProperties"].(map[string]interface{}); ok {
+			for p := range props {
+				if err := d.checkPattern(p, pointer+"/patternProperties/"+escapePointer(p)); err != nil {
+					return err
+				}
+			}
+		}
+		for key, child := range node {
+			switch key {
+			case "const", "enum", "default", "examples", "example":
+				continue
+			}
+			if err := d.check(child, pointer+"/"+escapePointer(key)); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, child := range node {
+			if err := d.check(child, pointer+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkPattern returns a RuleError for the pattern at pointer if it
+// does not compile.
+func (d *schemaDoc) checkPattern(p string, pointer string) error {
+	if _, err := d.loader.pattern(p); err != nil {
+		return &RuleError{Rule: "pattern", Param: d.name + "#" + pointer, Err: err}
+	}
+
+	return nil
+}
+
+// escapePointer escapes a key for use in a JSON Pointer.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// parseYAML parses a YAML document into the values that
+// encoding/json would decode the same document into.
+func parseYAML(data []byte) (interface{}, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+
+	return yamlValue(node.Content[0])
+}
+
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Tag == "!!merge" {
+				merged, err := yamlValue(node.Content[i+1])
+				if err != nil {
+					return nil, err
+				}
+				for k, v := range mergedMaps(merged) {
+					if _, ok := m[k]; !ok {
+						m[k] = v
+					}
+				}
+				continue
+			}
+			v, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[node.Content[i].Value] = v
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		a := make([]interface{}, len(node.Content))
+		for i, child := range node.Content {
+			v, err := yamlValue(child)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = v
+		}
+		return a, nil
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		err := node.Decode(&b)
+		return b, err
+	case "!!int", "!!float":
+		var f float64
+		err := node.Decode(&f)
+		return f, err
+	}
+
+	return node.Value, nil
+}
+
+// mergedMaps returns the fields that a YAML merge key adds, from a
+// mapping or a list of them.
+func mergedMaps(value interface{}) map[string]interface{} {
+	if m, ok := value.(map[string]interface{}); ok {
+		return m
+	}
+
+	m := make(map[string]interface{})
+	list, _ := value.([]interface{})
+	for _, item := range list {
+		for k, v := range mergedMaps(item) {
+			if _, ok := m[k]; !ok {
+				m[k] = v
+			}
+		}
+	}
+
+	return m
+}
+
+// resolve finds the document and node that a `$ref` points to.
+func (d *schemaDoc) resolve(ref string) (*schemaDoc, interface{}, error) {
+	file, fragment := ref, ""
+	if i := strings.IndexByte(ref, '#'); i >= 0 {
+		file, fragment = ref[:i], ref[i+1:]
+	}
+
+	if strings.Contains(file, "://") {
+		return nil, nil, fmt.Errorf("unable to resolve %q: only local references are supported", ref)
+	}
+
+	doc := d
+	if file != "" {
+		var err error
+		doc, err = d.loader.load(d.loader.join(d.name, file))
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to resolve %q: %w", ref, err)
+		}
+	}
+
+	node, err := jsonPointer(doc.root, fragment)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to resolve %q in %s: %w", ref, doc.name, err)
+	}
+
+	return doc, node, nil
+}
+
+// jsonPointer finds the node at an RFC 6901 JSON Pointer, such as
+// `/components/schemas/User`.
+func jsonPointer(root interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return root, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("anchor %q is not supported", pointer)
+	}
+
+	pointer, err := url.PathUnescape(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	node := root
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		var ok bool
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node, ok = n[token]
+		case []interface{}:
+			var i int
+			i, err = strconv.Atoi(token)
+			ok = err == nil && i >= 0 && i < len(n)
+			if ok {
+				node = n[i]
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("%q does not exist", token)
+		}
+	}
+
+	return node, nil
+}
+
+// Validate checks a value, such as one decoded by encoding/json,
+// against the schema. It returns PathErrors describing each part of
+// the value that is invalid, or nil if the value is valid.
+func (s *JSONSchema) Validate(value interface{}) error {
+	if errs := s.validate("value", value); len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (s *JSONSchema) validate(name string, value interface{}) PathErrors {
+	e := &schemaEval{root: name, errs: make(PathErrors)}
+	e.eval(s.doc, s.node, normalizeJSON(value), "")
+	return e.errs
+}
+
+// normalizeJSON converts numbers to float64, as encoding/json decodes
+// them, so that values from other sources compare equal.
+func normalizeJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			m[k] = normalizeJSON(child)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, child := range v {
+			a[i] = normalizeJSON(child)
+		}
+		return a
+	}
+
+	return value
+}
+
+// schemaEval collects the errors found while checking a value.
+type schemaEval struct {
+	root  string
+	errs  PathErrors
+	depth int
+}
+
+// maxRefDepth limits how many references can be followed without
+// moving into the value, to stop reference loops.
+const maxRefDepth = 64
+
+func (e *schemaEval) name(path string) string {
+	if path == "" {
+		return e.root
+	}
+
+	return path
+}
+
+func (e *schemaEval) fail(path string, format string, args ...interface{}) {
+	if _, ok := e.errs[path]; !ok {
+		e.errs[path] = fmt.Errorf("%s "+format, append([]interface{}{e.name(path)}, args...)...)
+	}
+}
+
+// valid reports whether the value matches a schema, without
+// recording any errors.
+func (e *schemaEval) valid(doc *schemaDoc, node interface{}, value interface{}, path string) bool {
+	sub := &schemaEval{root: e.root, errs: make(PathErrors), depth: e.depth}
+	sub.eval(doc, node, value, path)
+	return len(sub.errs) == 0
+}
+
+func (e *schemaEval) eval(doc *schemaDoc, node interface{}, value interface{}, path string) {
+	if b, ok := node.(bool); ok {
+		if !b {
+			e.fail(path, "is not allowed")
+		}
+		return
+	}
+
+	s, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		rdoc, rnode, err := doc.resolve(ref)
+		if err != nil || e.depth >= maxRefDepth {
+			e.fail(path, "cannot be validated: unable to resolve %s", ref)
+			return
+		}
+		e.depth++
+		e.eval(rdoc, rnode, value, path)
+		e.depth--
+	}
+
+	if value == nil && s["nullable"] == true {
+		return
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, value) {
+		e.fail(path, "must be %s", describeType(t))
+		return
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok && !containsJSON(enum, value) {
+		var options []string
+		for _, option := range enum {
+			options = append(options, formatJSON(option))
+		}
+		e.fail(path, "must be one of %s", strings.Join(options, ", "))
+	}
+
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, value) {
+		e.fail(path, "must be %s", formatJSON(c))
+	}
+
+	switch v := value.(type) {
+	case string:
+		e.evalString(doc, s, v, path)
+	case float64:
+		e.evalNumber(s, v, path)
+	case map[string]interface{}:
+		e.evalObject(doc, s, v, path)
+	case []interface{}:
+		e.evalArray(doc, s, v, path)
+	}
+
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			e.eval(doc, sub, value, path)
+		}
+	}
+
+	if any, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range any {
+			if e.valid(doc, sub, value, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			e.fail(path, "must match at least one of the allowed schemas")
+		}
+	}
+
+	if one, ok := s["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, sub := range one {
+			if e.valid(doc, sub, value, path) {
+				matches++
+			}
+		}
+		if matches == 0 {
+			e.fail(path, "must match one of the allowed schemas")
+		} else if matches > 1 {
+			e.fail(path, "must match only one of the allowed schemas")
+		}
+	}
+
+	if not, ok := s["not"]; ok && e.valid(doc, not, value, path) {
+		e.fail(path, "must not match the disallowed schema")
+	}
+
+	if cond, ok := s["if"]; ok {
+		if e.valid(doc, cond, value, path) {
+			if then, ok := s["then"]; ok {
+				e.eval(doc, then, value, path)
+			}
+		} else if els, ok := s["else"]; ok {
+			e.eval(doc, els, value, path)
+		}
+	}
+}
+
+func (e *schemaEval) evalString(doc *schemaDoc, s map[string]interface{}, v string, path string) {
+	length := utf8.RuneCountInString(v)
+	if min, ok := s["minLength"].(float64); ok && float64(length) < min {
+		e.fail(path, "must be at least %s characters", formatJSON(min))
+	}
+	if max, ok := s["maxLength"].(float64); ok && float64(length) > max {
+		e.fail(path, "must be no more than %s characters", formatJSON(max))
+	}
+
+	if p, ok := s["pattern"].(string); ok {
+		re, err := doc.loader.pattern(p)
+		if err != nil {
+			e.fail(path, "cannot be validated: unable to compile pattern `%s`", p)
+		} else if !re.MatchString(v) {
+			e.fail(path, "must match the pattern `%s`", p)
+		}
+	}
+
+	if format, ok := s["format"].(string); ok && !validFormat(format, v) {
+		e.fail(path, "must be a valid %s", format)
+	}
+}
+
+func (e *schemaEval) evalNumber(s map[string]interface{}, v float64, path string) {
+	if min, ok := s["minimum"].(float64); ok && v < min {
+		e.fail(path, "must be at least %s", formatJSON(min))
+	}
+	if max, ok := s["maximum"].(float64); ok && v > max {
+		e.fail(path, "must be no more than %s", formatJSON(max))
+	}
+	if min, ok := s["exclusiveMinimum"].(float64); ok && v <= min {
+		e.fail(path, "must be greater than %s", formatJSON(min))
+	}
+	if max, ok := s["exclusiveMaximum"].(float64); ok && v >= max {
+		e.fail(path, "must be less than %s", formatJSON(max))
+	}
+	if m, ok := s["multipleOf"].(float64); ok && m > 0 && !isMultiple(v, m) {
+		e.fail(path, "must be a multiple of %s", formatJSON(m))
+	}
+}
+
+func (e *schemaEval) evalObject(doc *schemaDoc, s map[string]interface{}, v map[string]interface{}, path string) {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, exists := v[name]; !exists {
+					e.fail(join(path, name), "is required")
+				}
+			}
+		}
+	}
+
+	props, _ := s["properties"].(map[string]interface{})
+	patterns, _ := s["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := join(path, key)
+		matched := false
+
+		if prop, ok := props[key]; ok {
+			e.eval(doc, prop, v[key], child)
+			matched = true
+		}
+
+		for p, sub := range patterns {
+			if re, err := doc.loader.pattern(p); err == nil && re.MatchString(key) {
+				e.eval(doc, sub, v[key], child)
+				matched = true
+			}
+		}
+
+		if !matched && hasAdditional {
+			if additional == false {
+				e.fail(child, "is not an allowed field")
+			} else {
+				e.eval(doc, additional, v[key], child)
+			}
+		}
+	}
+
+	if min, ok := s["minProperties"].(float64); ok && float64(len(v)) < min {
+		e.fail(path, "must have at least %s fields", formatJSON(min))
+	}
+	if max, ok := s["maxProperties"].(float64); ok && float64(len(v)) > max {
+		e.fail(path, "must have no more than %s fields", formatJSON(max))
+	}
+}
+
+func (e *schemaEval) evalArray(doc *schemaDoc, s map[string]interface{}, v []interface{}, path string) {
+	prefix, _ := s["prefixItems"].([]interface{})
+	items, hasItems := s["items"]
+
+	for i, item := range v {
+		child := join(path, strconv.Itoa(i))
+		if i < len(prefix) {
+			e.eval(doc, prefix[i], item, child)
+		} else if hasItems {
+			e.eval(doc, items, item, child)
+		}
+	}
+
+	if min, ok := s["minItems"].(float64); ok && float64(len(v)) < min {
+		e.fail(path, "must have at least %s items", formatJSON(min))
+	}
+	if max, ok := s["maxItems"].(float64); ok && float64(len(v)) > max {
+		e.fail(path, "must have no more than %s items", formatJSON(max))
+	}
+
+	if s["uniqueItems"] == true {
+		for i := range v {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(v[i], v[j]) {
+					e.fail(join(path, strconv.Itoa(i)), "must not be a duplicate value")
+					break
+				}
+			}
+		}
+	}
+}
+
+// isMultiple reports whether v is a multiple of m. They are compared
+// as the decimals that they were written as, rather than as binary
+// floats, so that 0.3 is a multiple of 0.1.
+func isMultiple(v float64, m float64) bool {
+	rv, ok := new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+	if !ok {
+		return false
+	}
+	rm, ok := new(big.Rat).SetString(strconv.FormatFloat(m, 'g', -1, 64))
+	if !ok {
+		return false
+	}
+
+	return rv.Quo(rv, rm).IsInt()
+}
+
+// matchesType reports whether value has one of the JSON types in t,
+// which is a type name or a list of them.
+func matchesType(t interface{}, value interface{}) bool {
+	var types []interface{}
+	switch t := t.(type) {
+	case string:
+		types = []interface{}{t}
+	case []interface{}:
+		types = t
+	default:
+		return true
+	}
+
+	for _, t := range types {
+		switch t {
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := value.(float64); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := value.(float64); ok && f == float64(int64(f)) {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]interface{}); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func describeType(t interface{}) string {
+	names := map[string]string{
+		"null":    "null",
+		"boolean": "a boolean",
+		"string":  "a string",
+		"number":  "a number",
+		"integer": "an integer",
+		"array":   "an array",
+		"object":  "an object",
+	}
+
+	var types []string
+	switch t := t.(type) {
+	case string:
+		types = []string{names[t]}
+	case []interface{}:
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types = append(types, names[s])
+			}
+		}
+	}
+
+	return strings.Join(types, " or ")
+}
+
+func containsJSON(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatJSON formats a JSON value for an error message.
+func formatJSON(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// validFormat reports whether s has the given format. Unknown
+// formats are treated as valid.
+func validFormat(format string, s string) bool {
+	var err error
+
+	switch format {
+	case "email":
+		err = checkEmail(s, nil)
+	case "idn-email":
+		err = checkEmail(s, Options{"mode": EmailIntl})
+	case "date":
+		_, err = time.Parse("2006-01-02", s)
+	case "time":
+		_, err = time.Parse("15:04:05Z07:00", strings.ToUpper(s))
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, strings.ToUpper(s))
+	case "duration":
+		_, err = ParseISODuration(s, false)
+	case "hostname":
+		return isHostname(s)
+	case "idn-hostname":
+		var ascii string
+		ascii, err = idnaToASCII(s)
+		return err == nil && isHostname(ascii)
+	case "ipv4":
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	case "ipv6":
+		return net.ParseIP(s) != nil && strings.Contains(s, ":")
+	case "uri":
+		var u *url.URL
+		u, err = url.Parse(s)
+		return err == nil && u.Scheme != ""
+	case "uri-reference":
+		_, err = url.Parse(s)
+	case "uuid":
+		return isUUID(s)
+	case "regex":
+		_, err = regexp.Compile(s)
+	}
+
+	return err == nil
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// schemaMap resolves a node's references until it finds a schema
+// object, so that its keywords can be read.
+func (d *schemaDoc) schemaMap(node interface{}) (*schemaDoc, map[string]interface{}) {
+	doc := d
+	for i := 0; i < maxRefDepth; i++ {
+		s, ok := node.(map[string]interface{})
+		if !ok {
+			return doc, nil
+		}
+
+		ref, ok := s["$ref"].(string)
+		if !ok {
+			return doc, s
+		}
+
+		rdoc, rnode, err := doc.resolve(ref)
+		if err != nil {
+			return doc, nil
+		}
+		doc, node = rdoc, rnode
+	}
+
+	return doc, nil
+}
+
+// property finds the schema of an object's property, looking through
+// references and `allOf`.
+func (d *schemaDoc) property(node interface{}, name string) (*schemaDoc, interface{}) {
+	doc, s := d.schemaMap(node)
+	if s == nil {
+		return nil, nil
+	}
+
+	if props, ok := s["properties"].(map[string]interface{}); ok {
+		if prop, ok := props[name]; ok {
+			return doc, prop
+		}
+	}
+
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if pdoc, prop := doc.property(sub, name); prop != nil {
+				return pdoc, prop
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// coerce converts values from a query string, header or form to the
+// types that the schema expects, so that `?page=2` matches an integer.
+// Values that cannot be converted are left as strings, so that the
+// schema reports them.
+func (d *schemaDoc) coerce(node interface{}, values []string) interface{} {
+	doc, s := d.schemaMap(node)
+
+	var types []interface{}
+	switch t := s["type"].(type) {
+	case string:
+		types = []interface{}{t}
+	case []interface{}:
+		types = t
+	}
+
+	for _, t := range types {
+		if t == "array" {
+			items := make([]interface{}, len(values))
+			for i, value := range values {
+				items[i] = doc.coerce(s["items"], []string{value})
+			}
+			return items
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	value := values[0]
+	for _, t := range types {
+		switch t {
+		case "integer", "number":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(value); err == nil {
+				return b
+			}
+		}
+	}
+
+	return value
+}
+
+// Rule returns a Rule that checks the request's body against the
+// schema. JSON bodies are decoded, and form bodies are converted to
+// an object with the values coerced to the types in the schema.
+// Errors are reported under `body`, followed by the path inside the
+// body, such as `body.address.city`.
+func (s *JSONSchema) Rule() Rule {
+	return Rule{Source: Body, Check: s.checkBody(true)}
+}
+
+// Validator creates a Validator that checks the request's body
+// against the schema.
+func (s *JSONSchema) Validator(r *http.Request) *Validator {
+	return Make(r, s.Rule())
+}
+
+func (s *JSONSchema) checkBody(required bool) CheckFunc {
+	return func(r *http.Request, _ string, _ Options) error {
+		body, limit := originalRequest(r.Context(), r), DefaultMaxBodySize
+		if rr := runFor(r.Context()); rr != nil {
+			limit = rr.settings.maxBodySize()
+		}
+
+		value, err := jsonBody(body, limit)
+		if err != nil {
+			return err
+		}
+
+		if value == nil && len(r.Form) > 0 {
+			object := make(map[string]interface{}, len(r.Form))
+			for name, values := range r.Form {
+				doc, prop := s.doc.property(s.node, name)
+				if prop == nil {
+					object[name] = s.doc.coerce(nil, values)
+					continue
+				}
+				object[name] = doc.coerce(prop, values)
+			}
+			value = object
+		}
+
+		if value == nil {
+			if required {
+				return fmt.Errorf("body is required")
+			}
+			return nil
+		}
+
+		if errs := s.validate("body", value); len(errs) > 0 {
+			return errs
+		}
+
+		return nil
+	}
+}
+
+// checkParam returns a CheckFunc that checks a query, path, header
+// or cookie parameter against the schema.
+func (s *JSONSchema) checkParam(required bool) CheckFunc {
+	return func(r *http.Request, param string, _ Options) error {
+		values, ok := r.Form[param]
+		if !ok {
+			if required {
+				return fmt.Errorf("%s is required", param)
+			}
+			return nil
+		}
+
+		if errs := s.validate(param, s.doc.coerce(s.node, values)); len(errs) > 0 {
+			return errs
+		}
+
+		return nil
+	}
+}
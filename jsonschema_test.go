@@ -0,0 +1,345 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+var schemaFiles = fstest.MapFS{
+	"schemas/user.json": {Data: []byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["email", "age"],
+		"additionalProperties": false,
+		"properties": {
+			"email": {"type": "string", "format": "email"},
+			"age": {"type": "integer", "minimum": 18},
+			"role": {"enum": ["admin", "editor"]},
+			"address": {"$ref": "common.json#/$defs/address"},
+			"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
+			"contact": {"oneOf": [
+				{"type": "string", "format": "uuid"},
+				{"type": "integer"}
+			]}
+		}
+	}`)},
+	"schemas/common.json": {Data: []byte(`{
+		"$defs": {
+			"address": {
+				"type": "object",
+				"required": ["city"],
+				"properties": {"city": {"type": "string", "minLength": 2}}
+			}
+		}
+	}`)},
+	"api.json": {Data: []byte(`{
+		"openapi": "3.1.0",
+		"paths": {
+			"/users/{id}": {
+				"parameters": [{"name": "id", "in": "path", "schema": {"type": "integer"}}],
+				"put": {
+					"operationId": "updateUser",
+					"parameters": [
+						{"$ref": "#/components/parameters/page"},
+						{"name": "X-Api-Key", "in": "header", "required": true, "schema": {"type": "string"}}
+					],
+					"requestBody": {
+						"required": true,
+						"content": {"application/json": {"schema": {"$ref": "schemas/user.json"}}}
+					}
+				}
+			}
+		},
+		"components": {
+			"parameters": {
+				"page": {"name": "page", "in": "query", "schema": {"type": "integer", "minimum": 1}}
+			}
+		}
+	}`)},
+}
+
+func TestJSONSchema(t *testing.T) {
+	s, err := LoadSchemaFS(schemaFiles, "schemas/user.json")
+	if err != nil {
+		fmt.Println("unable to load the schema:", err)
+		t.FailNow()
+	}
+
+	r, _ := http.NewRequest("POST", "localhost", strings.NewReader(`{
+		"email": "not an email",
+		"age": 17.5,
+		"role": "owner",
+		"address": {"city": "X"},
+		"tags": ["a", "a"],
+		"contact": 42,
+		"extra": true
+	}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	msgs, _ := s.Validator(r).Run()
+	want := map[string]string{
+		"body.email":        "email must be a valid email",
+		"body.age":          "age must be an integer",
+		"body.role":         "role must be one of admin, editor",
+		"body.address.city": "address.city must be at least 2 characters",
+		"body.tags.1":       "tags.1 must not be a duplicate value",
+		"body.extra":        "extra is not an allowed field",
+	}
+	if len(msgs) != len(want) {
+		fmt.Println("unexpected errors:", msgs)
+		t.FailNow()
+	}
+	for key, msg := range want {
+		if len(msgs[key]) == 0 || msgs[key][0] != msg {
+			fmt.Printf("expected %q for %s, got %v\n", msg, key, msgs[key])
+			t.FailNow()
+		}
+	}
+
+	err = s.Validate(map[string]interface{}{"email": "me@tomm.us", "age": 30, "contact": "x"})
+	if err == nil || err.Error() != "contact must match one of the allowed schemas" {
+		fmt.Println("unexpected error:", err)
+		t.FailNow()
+	}
+}
+
+func TestJSONSchemaForm(t *testing.T) {
+	s, _ := LoadSchemaFS(schemaFiles, "schemas/user.json")
+
+	r, _ := http.NewRequest("POST", "localhost", strings.NewReader("email=me@tomm.us&age=21&tags=a&tags=b"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if msgs, _ := s.Validator(r).Run(); len(msgs) > 0 {
+		fmt.Println("unexpected errors:", msgs)
+		t.FailNow()
+	}
+
+	r, _ = http.NewRequest("POST", "localhost", nil)
+	if msgs, _ := s.Validator(r).Run(); len(msgs["body"]) == 0 {
+		fmt.Println("expected a missing body to fail, got", msgs)
+		t.FailNow()
+	}
+}
+
+func TestLoadSchemaErrors(t *testing.T) {
+	cases := map[string]string{
+		"missing.json": "",
+		"invalid.json": `{"type": `,
+		"remote.json":  `{"$ref": "https://example.com/schema.json"}`,
+		"pointer.json": `{"$ref": "#/$defs/missing"}`,
+		"file.json":    `{"$ref": "other.json"}`,
+	}
+
+	fsys := fstest.MapFS{}
+	for name, data := range cases {
+		if data != "" {
+			fsys[name] = &fstest.MapFile{Data: []byte(data)}
+		}
+	}
+
+	for name := range cases {
+		if _, err := LoadSchemaFS(fsys, name); err == nil {
+			fmt.Println("expected an error loading", name)
+			t.FailNow()
+		}
+	}
+}
+
+func TestLoadSchemaCompilesPatterns(t *testing.T) {
+	cases := map[string]bool{
+		`{"properties": {"a": {"pattern": "("}}}`:           false,
+		`{"patternProperties": {"(": {"type": "string"}}}`:  false,
+		`{"items": [{"type": "string"}, {"pattern": "["}]}`: false,
+		`{"const": {"pattern": "("}}`:                       true,
+		`{"properties": {"pattern": {"type": "string"}}}`:   true,
+	}
+
+	for schema, ok := range cases {
+		_, err := ParseSchema([]byte(schema))
+		if ok != (err == nil) {
+			fmt.Println("unexpected error for", schema, err)
+			t.FailNow()
+		}
+
+		var re *RuleError
+		if !ok && (!errors.As(err, &re) || !errors.Is(err, ErrMisconfiguredRule)) {
+			fmt.Println("expected a RuleError for", schema, "got", err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestJSONSchemaMultipleOf(t *testing.T) {
+	s, _ := ParseSchema([]byte(`{"multipleOf": 0.1}`))
+	for _, v := range []float64{0.3, 1.1, 0.7, 12, -0.9} {
+		if err := s.Validate(v); err != nil {
+			fmt.Println("unexpected error for", v, err)
+			t.FailNow()
+		}
+	}
+	for _, v := range []float64{0.35, 0.01, 1.05} {
+		if err := s.Validate(v); err == nil {
+			fmt.Println("expected an error for", v)
+			t.FailNow()
+		}
+	}
+}
+
+func TestSchemaLoaderReadsOnce(t *testing.T) {
+	var mu sync.Mutex
+	reads := make(map[string]int)
+	l := fsLoader(schemaFiles)
+	read := l.read
+	l.read = func(name string) ([]byte, error) {
+		mu.Lock()
+		reads[name]++
+		mu.Unlock()
+		return read(name)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := l.schema("schemas/user.json"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for name, n := range reads {
+		if n != 1 {
+			fmt.Println("expected", name, "to be read once, got", n)
+			t.FailNow()
+		}
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "defs.json"), []byte(`{"$defs": {"a~b": {"type": "string", "pattern": "^[a-z]+$"}}}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "root.json"), []byte(`{"$ref": "defs.json#/$defs/a~0b"}`), 0o644)
+
+	s, err := LoadSchema(filepath.Join(dir, "root.json"))
+	if err != nil {
+		fmt.Println("unable to load the schema:", err)
+		t.FailNow()
+	}
+
+	if err := s.Validate("abc"); err != nil {
+		fmt.Println("unexpected error:", err)
+		t.FailNow()
+	}
+	if err := s.Validate("ABC"); err == nil || err.Error() != "value must match the pattern `^[a-z]+$`" {
+		fmt.Println("unexpected error:", err)
+		t.FailNow()
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	d, err := LoadOpenAPIFS(schemaFiles, "api.json")
+	if err != nil {
+		fmt.Println("unable to load the document:", err)
+		t.FailNow()
+	}
+
+	r, _ := http.NewRequest("PUT", "localhost/users/x?page=0", strings.NewReader(`{"email": "me@tomm.us", "age": 30}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.SetPathValue("id", "x")
+
+	v, err := d.Validator(r, "updateUser")
+	if err != nil {
+		fmt.Println("unable to create the validator:", err)
+		t.FailNow()
+	}
+
+	msgs, _ := v.Run()
+	want := map[string]string{
+		"path.id":          "id must be an integer",
+		"query.page":       "page must be at least 1",
+		"header.X-Api-Key": "X-Api-Key is required",
+	}
+	if len(msgs) != len(want) {
+		fmt.Println("unexpected errors:", msgs)
+		t.FailNow()
+	}
+	for key, msg := range want {
+		if len(msgs[key]) == 0 || msgs[key][0] != msg {
+			fmt.Printf("expected %q for %s, got %v\n", msg, key, msgs[key])
+			t.FailNow()
+		}
+	}
+
+	if rules, err := d.PathRules("PUT", "/users/{id}"); err != nil || len(rules) != 4 {
+		fmt.Println("unexpected rules:", rules, err)
+		t.FailNow()
+	}
+	if _, err := d.Rules("deleteUser"); err == nil {
+		fmt.Println("expected an error for an unknown operation")
+		t.FailNow()
+	}
+}
+
+func TestOpenAPIDocumentYAML(t *testing.T) {
+	fsys := fstest.MapFS{
+		"schemas/user.json":   schemaFiles["schemas/user.json"],
+		"schemas/common.json": schemaFiles["schemas/common.json"],
+		"api.yaml": {Data: []byte(`openapi: 3.1.0
+paths:
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        schema: {type: integer}
+    put:
+      operationId: updateUser
+      parameters:
+        - $ref: "#/components/parameters/page"
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: schemas/user.json}
+components:
+  parameters:
+    page:
+      name: page
+      in: query
+      schema:
+        type: integer
+        minimum: 1
+        default: 2020-01-01
+`)},
+	}
+
+	d, err := LoadOpenAPIFS(fsys, "api.yaml")
+	if err != nil {
+		fmt.Println("unable to load the document:", err)
+		t.FailNow()
+	}
+
+	r, _ := http.NewRequest("PUT", "localhost/users/1?page=0", strings.NewReader(`{"email": "me@tomm.us", "age": 30}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.SetPathValue("id", "1")
+
+	v, err := d.Validator(r, "updateUser")
+	if err != nil {
+		fmt.Println("unable to create the validator:", err)
+		t.FailNow()
+	}
+
+	msgs, _ := v.Run()
+	if len(msgs) != 1 || len(msgs["query.page"]) == 0 || msgs["query.page"][0] != "page must be at least 1" {
+		fmt.Println("unexpected errors:", msgs)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,428 @@
+package validate
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	// Embeds the IANA timezone database so that Timezone works
+	// on hosts without a zoneinfo directory.
+	_ "time/tzdata"
+)
+
+//go:embed data/iso3166.tsv
+var iso3166Data string
+
+//go:embed data/iso639.txt
+var iso639Data string
+
+//go:embed data/iso15924.txt
+var iso15924Data string
+
+//go:embed data/m49.txt
+var m49Data string
+
+//go:embed data/postcodes.tsv
+var postcodeData string
+
+// country holds the ISO 3166-1 codes for a single country.
+type country struct {
+	Alpha2  string
+	Alpha3  string
+	Numeric string
+	Name    string
+}
+
+var (
+	countriesByAlpha2 = make(map[string]country)
+	countriesByAlpha3 = make(map[string]country)
+	languages         = make(map[string]bool)
+	scripts           = make(map[string]bool)
+	regions           = make(map[string]bool)
+	postcodes         = make(map[string]*regexp.Regexp)
+)
+
+// genericPostcode is used for countries that have no entry in the
+// embedded postal code table.
+var genericPostcode = regexp.MustCompile(`^(?i:[A-Z0-9][A-Z0-9 \-]{1,9})$`)
+
+func init() {
+	for _, row := range readTable(iso3166Data) {
+		c := country{Alpha2: row[0], Alpha3: row[1], Numeric: row[2], Name: row[3]}
+		countriesByAlpha2[c.Alpha2] = c
+		countriesByAlpha3[c.Alpha3] = c
+		regions[c.Alpha2] = true
+		regions[c.Numeric] = true
+	}
+
+	for _, row := range readTable(iso639Data) {
+		languages[row[0]] = true
+	}
+
+	for _, row := range readTable(iso15924Data) {
+		scripts[strings.ToLower(row[0])] = true
+	}
+
+	for _, row := range readTable(m49Data) {
+		regions[row[0]] = true
+	}
+
+	for _, row := range readTable(postcodeData) {
+		if row[1] == "-" {
+			postcodes[row[0]] = nil
+			continue
+		}
+		postcodes[row[0]] = regexp.MustCompile(`^(?i:` + row[1] + `)$`)
+	}
+}
+
+// readTable splits embedded data into rows of tab separated
+// columns, skipping blank lines and `#` comments.
+func readTable(data string) [][]string {
+	var rows [][]string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rows = append(rows, strings.Split(line, "\t"))
+	}
+
+	return rows
+}
+
+// lookupCountry finds a country by its alpha-2 or alpha-3 code,
+// ignoring case.
+func lookupCountry(code string) (country, bool) {
+	code = strings.ToUpper(code)
+	switch len(code) {
+	case 2:
+		c, ok := countriesByAlpha2[code]
+		return c, ok
+	case 3:
+		c, ok := countriesByAlpha3[code]
+		return c, ok
+	}
+
+	return country{}, false
+}
+
+// Country returns an error if the parameter is not an ISO 3166-1
+// country code. Both alpha-2 (`GB`) and alpha-3 (`GBR`) codes are
+// accepted unless a `format` of "alpha2" or "alpha3" is passed in
+// the Options map.
+var Country CheckFunc = func(r *http.Request, param string, o Options) error {
+	return countryField(r.Context(), requestField(r, param, o))
+}
+
+func countryField(ctx context.Context, f Field) error {
+	value := f.String()
+
+	format, err := countryFormat(f.Options)
+	if err != nil {
+		return misconfigured(f.Name, "%w", err)
+	}
+
+	switch format {
+	case "alpha2":
+		if len(value) != 2 {
+			return fmt.Errorf("%s must be an ISO 3166-1 alpha-2 country code", f.Name)
+		}
+	case "alpha3":
+		if len(value) != 3 {
+			return fmt.Errorf("%s must be an ISO 3166-1 alpha-3 country code", f.Name)
+		}
+	}
+
+	if _, ok := lookupCountry(value); !ok {
+		return fmt.Errorf("%s must be a valid country code", f.Name)
+	}
+
+	return nil
+}
+
+// countryFormat returns the `format` in the Options map, which is
+// empty if both formats are accepted.
+func countryFormat(o Options) (string, error) {
+	format, ok := o["format"].(string)
+	if _, set := o["format"]; set && !ok {
+		return "", errors.New("format must be a string")
+	}
+
+	switch format {
+	case "", "alpha2", "alpha3":
+		return format, nil
+	}
+
+	return "", fmt.Errorf("unknown format %q", format)
+}
+
+// Language returns an error if the parameter is not a well-formed
+// BCP 47 language tag, such as `en`, `en-GB` or `zh-Hant-TW`. The
+// language, script and region subtags are checked against the
+// embedded ISO 639, ISO 15924 and ISO 3166-1 code lists.
+var Language CheckFunc = func(r *http.Request, param string, o Options) error {
+	return languageField(r.Context(), requestField(r, param, o))
+}
+
+func languageField(ctx context.Context, f Field) error {
+	if !isLanguageTag(f.String()) {
+		return fmt.Errorf("%s must be a valid language tag", f.Name)
+	}
+
+	return nil
+}
+
+// Timezone returns an error if the parameter is not an IANA time
+// zone name, such as `Europe/London`. The timezone database is
+// embedded, so this does not depend on the host's zoneinfo files.
+var Timezone CheckFunc = func(r *http.Request, param string, o Options) error {
+	return timezoneField(r.Context(), requestField(r, param, o))
+}
+
+func timezoneField(ctx context.Context, f Field) error {
+	value := f.String()
+
+	// LoadLocation treats these as special cases rather than
+	// zone names, so they are never valid input.
+	if value == "" || value == "Local" {
+		return fmt.Errorf("%s must be a valid timezone", f.Name)
+	}
+
+	if _, err := time.LoadLocation(value); err != nil {
+		return fmt.Errorf("%s must be a valid timezone", f.Name)
+	}
+
+	return nil
+}
+
+// PostalCode returns an error if the parameter is not a valid
+// postal code for a country. The country is either given directly
+// with a `country` key in the Options map, or read from another
+// request parameter named by the `field` key. The field is named as
+// it is reported, so a `field` of `query.country` reads the URL's
+// query whatever the Source of the PostalCode rule.
+//
+// Countries without an entry in the embedded table are checked
+// against a generic alphanumeric pattern. Countries that do not
+// use postal codes only accept an empty value.
+var PostalCode CheckFunc = func(r *http.Request, param string, o Options) error {
+	return postalCodeField(r.Context(), requestField(r, param, o))
+}
+
+func postalCodeField(ctx context.Context, f Field) error {
+	value := f.String()
+
+	code, ok := f.Options["country"].(string)
+	if !ok {
+		field, ok := f.Options["field"].(string)
+		if !ok {
+			return misconfigured(f.Name, "country or field must be a string")
+		}
+		if values := lookup(ctx, f, field); len(values) > 0 {
+			code = values[0]
+		}
+	}
+
+	c, ok := lookupCountry(code)
+	if !ok {
+		return fmt.Errorf("%s cannot be validated without a valid country", f.Name)
+	}
+
+	pattern, known := postcodes[c.Alpha2]
+	if !known {
+		pattern = genericPostcode
+	}
+
+	if pattern == nil {
+		if value != "" {
+			return fmt.Errorf("%s must be empty, %s does not use postal codes", f.Name, c.Name)
+		}
+		return nil
+	}
+
+	if !pattern.MatchString(value) {
+		return fmt.Errorf("%s is not a valid postal code for %s", f.Name, c.Name)
+	}
+
+	return nil
+}
+
+// grandfathered holds the irregular and regular tags from RFC 5646
+// that do not follow the normal langtag production.
+var grandfathered = map[string]bool{
+	"en-gb-oed": true, "i-ami": true, "i-bnn": true, "i-default": true,
+	"i-enochian": true, "i-hak": true, "i-klingon": true, "i-lux": true,
+	"i-mingo": true, "i-navajo": true, "i-pwn": true, "i-tao": true,
+	"i-tay": true, "i-tsu": true, "sgn-be-fr": true, "sgn-be-nl": true,
+	"sgn-ch-de": true, "art-lojban": true, "cel-gaulish": true,
+	"no-bok": true, "no-nyn": true, "zh-guoyu": true, "zh-hakka": true,
+	"zh-min": true, "zh-min-nan": true, "zh-xiang": true,
+}
+
+// isLanguageTag reports whether tag follows the RFC 5646 grammar
+// and uses known language, script and region subtags.
+func isLanguageTag(tag string) bool {
+	tag = strings.ToLower(tag)
+	if grandfathered[tag] {
+		return true
+	}
+
+	subtags := strings.Split(tag, "-")
+	for _, s := range subtags {
+		if s == "" || len(s) > 8 || !isAlphanumeric(s) {
+			return false
+		}
+	}
+
+	// A tag made entirely of private use subtags.
+	if subtags[0] == "x" {
+		return len(subtags) > 1
+	}
+
+	i := 0
+	if !isLanguageSubtag(subtags[i]) {
+		return false
+	}
+	i++
+
+	// Up to three extended language subtags.
+	for n := 0; n < 3 && i < len(subtags) && len(subtags[i]) == 3 && isAlpha(subtags[i]); n++ {
+		if !languages[subtags[i]] {
+			return false
+		}
+		i++
+	}
+
+	if i < len(subtags) && len(subtags[i]) == 4 && isAlpha(subtags[i]) {
+		if !isScriptSubtag(subtags[i]) {
+			return false
+		}
+		i++
+	}
+
+	if i < len(subtags) && isRegionShape(subtags[i]) {
+		if !isRegionSubtag(subtags[i]) {
+			return false
+		}
+		i++
+	}
+
+	variants := make(map[string]bool)
+	for i < len(subtags) && isVariantShape(subtags[i]) {
+		if variants[subtags[i]] {
+			return false
+		}
+		variants[subtags[i]] = true
+		i++
+	}
+
+	singletons := make(map[string]bool)
+	for i < len(subtags) && len(subtags[i]) == 1 && subtags[i] != "x" {
+		if singletons[subtags[i]] {
+			return false
+		}
+		singletons[subtags[i]] = true
+		i++
+
+		start := i
+		for i < len(subtags) && len(subtags[i]) >= 2 {
+			i++
+		}
+		if i == start {
+			return false
+		}
+	}
+
+	if i < len(subtags) && subtags[i] == "x" {
+		return i+1 < len(subtags)
+	}
+
+	return i == len(subtags)
+}
+
+func isLanguageSubtag(s string) bool {
+	if !isAlpha(s) {
+		return false
+	}
+
+	switch len(s) {
+	case 2:
+		return languages[s]
+	case 3:
+		// qaa..qtz is reserved for private use.
+		if s[0] == 'q' && s[1] >= 'a' && s[1] <= 't' {
+			return true
+		}
+		return languages[s]
+	}
+
+	return false
+}
+
+func isScriptSubtag(s string) bool {
+	// Qaaa..Qabx is reserved for private use.
+	if s >= "qaaa" && s <= "qabx" {
+		return true
+	}
+
+	return scripts[s]
+}
+
+func isRegionShape(s string) bool {
+	return (len(s) == 2 && isAlpha(s)) || (len(s) == 3 && isDigits(s))
+}
+
+func isRegionSubtag(s string) bool {
+	s = strings.ToUpper(s)
+
+	// AA, QM..QZ, XA..XZ and ZZ are reserved for private use.
+	if s == "AA" || s == "ZZ" || (s >= "QM" && s <= "QZ") || (len(s) == 2 && s[0] == 'X') {
+		return true
+	}
+
+	return regions[s]
+}
+
+func isVariantShape(s string) bool {
+	if len(s) >= 5 {
+		return true
+	}
+
+	return len(s) == 4 && s[0] >= '0' && s[0] <= '9'
+}
+
+func isAlpha(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,144 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestLocaleRules(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+
+	rules := []struct {
+		Check   CheckFunc
+		Passes  []string
+		Fails   []string
+		Options Options
+	}{
+		{
+			Country,
+			[]string{"GB", "gb", "USA", "deu"},
+			[]string{"", "UK", "XX", "GBRR", "G"},
+			nil,
+		},
+		{
+			Country,
+			[]string{"GB", "FR"},
+			[]string{"GBR", "FRA"},
+			Options{"format": "alpha2"},
+		},
+		{
+			Country,
+			[]string{"GBR", "FRA"},
+			[]string{"GB", "FR"},
+			Options{"format": "alpha3"},
+		},
+		{
+			Language,
+			[]string{
+				"en", "en-GB", "EN-gb", "zh-Hant-TW", "sr-Latn-RS", "es-419",
+				"de-CH-1996", "en-US-u-ca-gregory", "x-whatever", "i-klingon",
+				"zh-yue-HK", "en-a-bbb-x-a-ccc",
+			},
+			[]string{
+				"", "english", "en_GB", "zz", "en-", "en-Zyxw", "en-QQ1",
+				"de-1996-1996", "en-a-bbb-a-ccc", "en-x", "en-u", "ab-cd-ef-gh-ij",
+			},
+			nil,
+		},
+		{
+			Timezone,
+			[]string{"Europe/London", "America/New_York", "UTC", "Asia/Kolkata"},
+			[]string{"", "Local", "Europe/Nowhere", "GMT+25", "../etc/passwd"},
+			nil,
+		},
+		{
+			PostalCode,
+			[]string{"SW1A 1AA", "sw1a1aa", "M1 1AE", "GIR 0AA"},
+			[]string{"", "12345", "SW1A"},
+			Options{"country": "GB"},
+		},
+		{
+			PostalCode,
+			[]string{"12345", "12345-6789"},
+			[]string{"1234", "ABCDE"},
+			Options{"country": "USA"},
+		},
+		{
+			PostalCode,
+			[]string{""},
+			[]string{"12345"},
+			Options{"country": "AE"},
+		},
+	}
+
+	for _, rule := range rules {
+		for _, value := range rule.Passes {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: rule.Check, Options: rule.Options})
+			if len(msgs) > 0 {
+				fmt.Println("Got an error, expected none:", msgs["parameter"])
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+
+		for _, value := range rule.Fails {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: rule.Check, Options: rule.Options})
+			if len(msgs) == 0 {
+				fmt.Println("Expected an error, didn't get one")
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestPostalCodeUsesCountryField(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+
+	rule := Rule{
+		Param:   "postcode",
+		Check:   PostalCode,
+		Options: Options{"field": "country"},
+	}
+
+	r.Form.Set("postcode", "75008")
+	r.Form.Set("country", "FR")
+	if msgs, _ := Check(r, rule); len(msgs) > 0 {
+		fmt.Println("expected a French postcode to pass, got", msgs)
+		t.FailNow()
+	}
+
+	r.Form.Set("country", "NL")
+	if msgs, _ := Check(r, rule); len(msgs) == 0 {
+		fmt.Println("expected a French postcode to fail for NL")
+		t.FailNow()
+	}
+
+	r.Form.Set("country", "")
+	if msgs, _ := Check(r, rule); len(msgs) == 0 {
+		fmt.Println("expected a missing country to fail")
+		t.FailNow()
+	}
+}
+
+func TestCountryFormatMustBeKnown(t *testing.T) {
+	r, _ := http.NewRequest("GET", "localhost?country=GB", nil)
+	r.ParseForm()
+
+	for _, o := range []Options{{"format": "name"}, {"format": 2}} {
+		if err := Country(r, "country", o); !errors.Is(err, ErrMisconfiguredRule) {
+			fmt.Printf("expected a configuration error for %v, got %v\n", o, err)
+			t.FailNow()
+		}
+		if _, err := Compile(Rule{Param: "country", Check: Country, Options: o}); !errors.Is(err, ErrMisconfiguredRule) {
+			fmt.Printf("expected Compile to reject %v, got %v\n", o, err)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package validate
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Operation is the part of an OpenAPI 3.1 Operation Object that
+// describes its inputs. It marshals to the `parameters` and
+// `requestBody` of the operation.
+type Operation struct {
+	Parameters  []Parameter  `json:"parameters,omitempty"`
+	RequestBody *RequestBody `json:"requestBody,omitempty"`
+}
+
+// Parameter is an OpenAPI Parameter Object.
+type Parameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required,omitempty"`
+	Schema   Schema `json:"schema"`
+}
+
+// RequestBody is an OpenAPI Request Body Object.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// MediaType is an OpenAPI Media Type Object.
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+// OpenAPI describes the rules as the inputs of an OpenAPI operation.
+// Rules with a Query, Header, Cookie or Path Source become
+// parameters, and the others are described by SchemaFor as the
+// request body. The body can be sent as a form or as JSON, or as a
+// multipart form if any rule checks uploaded files.
+func OpenAPI(rules ...Rule) Operation {
+	var op Operation
+
+	params := make(map[Source]map[string]int)
+	var body []Rule
+	for _, rule := range rules {
+		if rule.Source == Form || rule.Source == Body {
+			body = append(body, rule)
+			continue
+		}
+		if !rule.Source.valid() {
+			continue
+		}
+
+		if params[rule.Source] == nil {
+			params[rule.Source] = make(map[string]int)
+		}
+		i, ok := params[rule.Source][rule.Param]
+		if !ok {
+			i = len(op.Parameters)
+			params[rule.Source][rule.Param] = i
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:     rule.Param,
+				In:       string(rule.Source),
+				Required: rule.Source == Path,
+				Schema:   Schema{},
+			})
+		}
+
+		p := &op.Parameters[i]
+		p.Required = p.Required || isRequired(rule)
+		for k, v := range ruleSchema(rule) {
+			p.Schema[k] = v
+		}
+	}
+
+	if len(body) > 0 {
+		schema := SchemaFor(body...)
+		_, required := schema["required"]
+		op.RequestBody = &RequestBody{Required: required, Content: map[string]MediaType{}}
+
+		if hasFileRule(body) {
+			op.RequestBody.Content["multipart/form-data"] = MediaType{schema}
+		} else {
+			op.RequestBody.Content["application/x-www-form-urlencoded"] = MediaType{schema}
+			op.RequestBody.Content["application/json"] = MediaType{schema}
+		}
+	}
+
+	return op
+}
+
+// OpenAPI describes the Validator's rules as the inputs of an OpenAPI
+// operation. See OpenAPI.
+func (v *Validator) OpenAPI() Operation {
+	return OpenAPI(v.Rules...)
+}
+
+// hasFileRule reports whether any of the rules checks uploaded files.
+func hasFileRule(rules []Rule) bool {
+	for _, rule := range rules {
+		if _, ok := ruleSchema(rule)["contentMediaType"]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpenAPIDocument is an OpenAPI 3.0 or 3.1 document, in JSON or YAML,
+// loaded from local files. Files whose names end in `.yaml` or `.yml`
+// are read as YAML. Rules can be built from its operations.
+type OpenAPIDocument struct {
+	doc *schemaDoc
+}
+
+// LoadOpenAPI reads an OpenAPI document from a local file.
+func LoadOpenAPI(name string) (*OpenAPIDocument, error) {
+	doc, err := osLoader().load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenAPIDocument{doc}, nil
+}
+
+// LoadOpenAPIFS reads an OpenAPI document from a file in fsys.
+func LoadOpenAPIFS(fsys fs.FS, name string) (*OpenAPIDocument, error) {
+	doc, err := fsLoader(fsys).load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenAPIDocument{doc}, nil
+}
+
+// Rules returns the Rules for the operation with the given
+// operationId. Each parameter becomes a Rule with the parameter's
+// Source, which checks it against its schema, and the request body,
+// if there is one, becomes a Rule with the Body source.
+func (d *OpenAPIDocument) Rules(operationID string) ([]Rule, error) {
+	paths, _ := d.object(d.doc.root)["paths"].(map[string]interface{})
+	for _, item := range paths {
+		item := d.object(item)
+		for _, method := range openAPIMethods {
+			op := d.object(item[method])
+			if op != nil && op["operationId"] == operationID {
+				return d.operationRules(item, op)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("operation %q does not exist", operationID)
+}
+
+// PathRules returns the Rules for the operation with the given method
+// and path template, such as `GET` and `/users/{id}`. See Rules.
+func (d *OpenAPIDocument) PathRules(method string, path string) ([]Rule, error) {
+	paths, _ := d.object(d.doc.root)["paths"].(map[string]interface{})
+	item := d.object(paths[path])
+	op := d.object(item[strings.ToLower(method)])
+	if op == nil {
+		return nil, fmt.Errorf("operation %s %s does not exist", method, path)
+	}
+
+	return d.operationRules(item, op)
+}
+
+// Validator creates a Validator with the Rules for the operation with
+// the given operationId.
+func (d *OpenAPIDocument) Validator(r *http.Request, operationID string) (*Validator, error) {
+	rules, err := d.Rules(operationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return Make(r, rules...), nil
+}
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// object resolves a node's references, and returns it as an object.
+func (d *OpenAPIDocument) object(node interface{}) map[string]interface{} {
+	_, m := d.doc.schemaMap(node)
+	return m
+}
+
+func (d *OpenAPIDocument) operationRules(item map[string]interface{}, op map[string]interface{}) ([]Rule, error) {
+	var rules []Rule
+
+	// Operation parameters override path parameters with the same
+	// name and location.
+	type key struct{ name, in string }
+	params := make(map[key]int)
+	for _, list := range []interface{}{item["parameters"], op["parameters"]} {
+		list, _ := list.([]interface{})
+		for _, node := range list {
+			doc, p := d.doc.schemaMap(node)
+			if p == nil {
+				continue
+			}
+
+			name, _ := p["name"].(string)
+			in, _ := p["in"].(string)
+			source := Source(in)
+			if name == "" || source == Form || source == Body || !source.valid() {
+				return nil, fmt.Errorf("parameter %q in %q is not supported", name, in)
+			}
+
+			required := p["required"] == true || source == Path
+			schema := &JSONSchema{doc, p["schema"]}
+			rule := Rule{Param: name, Source: source, Check: schema.checkParam(required)}
+
+			if i, ok := params[key{name, in}]; ok {
+				rules[i] = rule
+				continue
+			}
+			params[key{name, in}] = len(rules)
+			rules = append(rules, rule)
+		}
+	}
+
+	if doc, body := d.doc.schemaMap(op["requestBody"]); body != nil {
+		content, _ := body["content"].(map[string]interface{})
+		if schema := bodySchema(doc, content); schema != nil {
+			rules = append(rules, Rule{Source: Body, Check: schema.checkBody(body["required"] == true)})
+		}
+	}
+
+	return rules, nil
+}
+
+// bodySchema picks the schema of a request body, preferring JSON.
+func bodySchema(doc *schemaDoc, content map[string]interface{}) *JSONSchema {
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return mediaRank(types[i]) < mediaRank(types[j]) ||
+			mediaRank(types[i]) == mediaRank(types[j]) && types[i] < types[j]
+	})
+
+	for _, t := range types {
+		mdoc, media := doc.schemaMap(content[t])
+		if schema, ok := media["schema"]; ok {
+			return &JSONSchema{mdoc, schema}
+		}
+	}
+
+	return nil
+}
+
+func mediaRank(t string) int {
+	switch {
+	case t == "application/json":
+		return 0
+	case strings.HasSuffix(t, "+json"):
+		return 1
+	case t == "application/x-www-form-urlencoded":
+		return 2
+	case t == "multipart/form-data":
+		return 3
+	}
+
+	return 4
+}
@@ -0,0 +1,309 @@
+package validate
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//go:embed data/phone.tsv
+var phoneData string
+
+// The types of number that can be identified by ParsePhone.
+const (
+	PhoneMobile            = "mobile"
+	PhoneFixedLine         = "fixed_line"
+	PhoneFixedLineOrMobile = "fixed_line_or_mobile"
+	PhoneTollFree          = "toll_free"
+)
+
+// phoneRegion holds the numbering metadata for a single region.
+type phoneRegion struct {
+	Region      string
+	CallingCode string
+	TrunkPrefix string
+	Lengths     map[int]bool
+	Mobile      *regexp.Regexp
+	FixedLine   *regexp.Regexp
+	TollFree    *regexp.Regexp
+	// Leading matches the start of the region's numbers, if it
+	// shares its calling code with a larger region.
+	Leading *regexp.Regexp
+}
+
+var (
+	phoneRegions      = make(map[string]*phoneRegion)
+	phoneCallingCodes = make(map[string][]*phoneRegion)
+)
+
+func init() {
+	for _, row := range readTable(phoneData) {
+		pr := &phoneRegion{
+			Region:      row[0],
+			CallingCode: row[1],
+			Lengths:     make(map[int]bool),
+			Mobile:      phonePattern(row[4]),
+			FixedLine:   phonePattern(row[5]),
+			TollFree:    phonePattern(row[6]),
+		}
+
+		if row[7] != "-" {
+			pr.Leading = regexp.MustCompile(`^(?:` + row[7] + `)`)
+		}
+
+		if row[2] != "-" {
+			pr.TrunkPrefix = row[2]
+		}
+
+		for _, l := range strings.Split(row[3], ",") {
+			n, err := strconv.Atoi(l)
+			if err != nil {
+				panic(fmt.Sprintf("validate: invalid phone length %q for %s", l, pr.Region))
+			}
+			pr.Lengths[n] = true
+		}
+
+		phoneRegions[pr.Region] = pr
+		phoneCallingCodes[pr.CallingCode] = append(phoneCallingCodes[pr.CallingCode], pr)
+	}
+}
+
+func phonePattern(p string) *regexp.Regexp {
+	if p == "-" {
+		return nil
+	}
+
+	return regexp.MustCompile(`^(?:` + p + `)$`)
+}
+
+// PhoneNumber is a telephone number that has been parsed and
+// checked against the embedded numbering metadata.
+type PhoneNumber struct {
+	// Region is the ISO 3166-1 alpha-2 code of the region the
+	// number belongs to.
+	Region string
+	// CallingCode is the country calling code, without a `+`.
+	CallingCode string
+	// NationalNumber is the national significant number, without
+	// any trunk prefix.
+	NationalNumber string
+	// Type is one of the Phone* type constants.
+	Type string
+}
+
+// E164 returns the number in E.164 format, such as `+447700900123`.
+func (p PhoneNumber) E164() string {
+	return "+" + p.CallingCode + p.NationalNumber
+}
+
+// ParsePhone parses a telephone number written in international
+// (`+44 7700 900123` or `0044...`) or national (`07700 900123`)
+// format. Numbers in national format need a default region, given
+// as an ISO 3166-1 country code. Spaces, dots, dashes and brackets
+// are ignored. Where regions share a calling code, such as Canada
+// and the US within +1, the region is chosen by the number's leading
+// digits.
+func ParsePhone(value string, region string) (PhoneNumber, error) {
+	digits, international, ok := phoneDigits(value)
+	if !ok || digits == "" {
+		return PhoneNumber{}, fmt.Errorf("%q is not a phone number", value)
+	}
+
+	if international {
+		for i := 1; i <= 3 && i < len(digits); i++ {
+			// Every region for the calling code is tried, so that
+			// a smaller region, such as Kazakhstan within +7, is
+			// chosen over the larger one whose patterns also match.
+			var number PhoneNumber
+			for _, pr := range phoneCallingCodes[digits[:i]] {
+				t := pr.classify(digits[i:])
+				if t == "" {
+					continue
+				}
+				if pr.Leading != nil {
+					return PhoneNumber{pr.Region, pr.CallingCode, digits[i:], t}, nil
+				}
+				if number.Region == "" {
+					number = PhoneNumber{pr.Region, pr.CallingCode, digits[i:], t}
+				}
+			}
+			if number.Region != "" {
+				return number, nil
+			}
+		}
+
+		return PhoneNumber{}, fmt.Errorf("%q is not a valid international phone number", value)
+	}
+
+	if region == "" {
+		return PhoneNumber{}, fmt.Errorf("%q must include a country calling code", value)
+	}
+
+	if c, ok := lookupCountry(region); ok {
+		region = c.Alpha2
+	}
+
+	pr, ok := phoneRegions[strings.ToUpper(region)]
+	if !ok {
+		return PhoneNumber{}, fmt.Errorf("no phone metadata exists for region %s", region)
+	}
+
+	national := digits
+	if pr.TrunkPrefix != "" {
+		national = strings.TrimPrefix(digits, pr.TrunkPrefix)
+	}
+
+	t := pr.classify(national)
+	if t == "" {
+		return PhoneNumber{}, fmt.Errorf("%q is not a valid phone number for %s", value, pr.Region)
+	}
+
+	return PhoneNumber{pr.Region, pr.CallingCode, national, t}, nil
+}
+
+// classify returns the type of the national number, or an empty
+// string if it is not valid for the region.
+func (pr *phoneRegion) classify(national string) string {
+	if !pr.Lengths[len(national)] {
+		return ""
+	}
+	if pr.Leading != nil && !pr.Leading.MatchString(national) {
+		return ""
+	}
+
+	if pr.TollFree != nil && pr.TollFree.MatchString(national) {
+		return PhoneTollFree
+	}
+
+	mobile := pr.Mobile != nil && pr.Mobile.MatchString(national)
+	fixed := pr.FixedLine != nil && pr.FixedLine.MatchString(national)
+
+	switch {
+	case mobile && fixed:
+		return PhoneFixedLineOrMobile
+	case mobile:
+		return PhoneMobile
+	case fixed:
+		return PhoneFixedLine
+	}
+
+	return ""
+}
+
+// phoneDigits strips formatting characters from a phone number
+// and reports whether it was written in international format.
+func phoneDigits(value string) (string, bool, bool) {
+	value = strings.TrimSpace(value)
+
+	international := false
+	if strings.HasPrefix(value, "+") {
+		international = true
+		value = value[1:]
+	}
+
+	var b strings.Builder
+	for _, c := range value {
+		switch {
+		case c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c == ' ' || c == '-' || c == '.' || c == '(' || c == ')':
+		default:
+			return "", false, false
+		}
+	}
+
+	digits := b.String()
+	if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+
+	// E.164 numbers are at most 15 digits including the
+	// country calling code.
+	if len(digits) > 15 {
+		return "", false, false
+	}
+
+	return digits, international, true
+}
+
+// Phone returns an error if the parameter is not a valid phone
+// number. Numbers without a country calling code are parsed using
+// the `region` key in the Options map, e.g. "GB". The number types
+// that are allowed can be restricted by passing a slice of the
+// Phone* type constants with a `types` key, for example to only
+// accept mobile numbers. A region without phone metadata, or an
+// unknown type, makes the rule misconfigured.
+//
+// The parsed PhoneNumber is returned by the Validator's Validated
+// method, and its E164 method gives the number in E.164 format.
+var Phone CheckFunc = func(r *http.Request, param string, o Options) error {
+	return phoneField(r.Context(), requestField(r, param, o))
+}
+
+func phoneField(ctx context.Context, f Field) error {
+	region, types, err := phoneOptions(f.Options)
+	if err != nil {
+		return misconfigured(f.Name, "%w", err)
+	}
+
+	number, err := ParsePhone(f.String(), region)
+	if err != nil {
+		return fmt.Errorf("%s must be a valid phone number", f.Name)
+	}
+
+	setValue(ctx, f.Name, number)
+
+	if types == nil {
+		return nil
+	}
+
+	for _, t := range types {
+		if number.Type == t {
+			return nil
+		}
+
+		if number.Type == PhoneFixedLineOrMobile && (t == PhoneMobile || t == PhoneFixedLine) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s must be a %s phone number", f.Name, strings.ReplaceAll(strings.Join(types, " or "), "_", " "))
+}
+
+// phoneOptions returns the `region` and `types` in the Options map,
+// or an error if the region has no metadata or a type is unknown.
+func phoneOptions(o Options) (string, []string, error) {
+	region, ok := o["region"].(string)
+	if _, set := o["region"]; set && !ok {
+		return "", nil, errors.New("region must be a string")
+	}
+	if region != "" {
+		code := region
+		if c, ok := lookupCountry(region); ok {
+			code = c.Alpha2
+		}
+		if _, ok := phoneRegions[strings.ToUpper(code)]; !ok {
+			return "", nil, fmt.Errorf("no phone metadata exists for region %s", region)
+		}
+	}
+
+	types, ok := o["types"].([]string)
+	if _, set := o["types"]; set && !ok {
+		return "", nil, errors.New("types must be a []string")
+	}
+	for _, t := range types {
+		switch t {
+		case PhoneMobile, PhoneFixedLine, PhoneFixedLineOrMobile, PhoneTollFree:
+		default:
+			return "", nil, fmt.Errorf("unknown phone type %q", t)
+		}
+	}
+
+	return region, types, nil
+}
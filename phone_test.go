@@ -0,0 +1,150 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestPhoneRule(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+
+	rules := []struct {
+		Passes  []string
+		Fails   []string
+		Options Options
+	}{
+		{
+			[]string{"+44 7700 900123", "+1 (415) 555-2671", "0033 6 12 34 56 78", "+49 30 123456"},
+			[]string{"", "07700 900123", "+999 1234567", "+44 7700", "+44 7700 900123 4", "phone"},
+			nil,
+		},
+		{
+			[]string{"07700 900123", "020 7946 0018", "+33612345678", "0800 123 4567"},
+			[]string{"7700 9001", "0770090012345", "+44 0000 000000"},
+			Options{"region": "GB"},
+		},
+		{
+			[]string{"07700 900123", "+1 415 555 2671"},
+			[]string{"020 7946 0018", "0800 123 4567"},
+			Options{"region": "GB", "types": []string{PhoneMobile}},
+		},
+	}
+
+	for _, rule := range rules {
+		for _, value := range rule.Passes {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: Phone, Options: rule.Options})
+			if len(msgs) > 0 {
+				fmt.Println("Got an error, expected none:", msgs["parameter"])
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+
+		for _, value := range rule.Fails {
+			r.Form.Set("parameter", value)
+			msgs, _ := Check(r, Rule{Param: "parameter", Check: Phone, Options: rule.Options})
+			if len(msgs) == 0 {
+				fmt.Println("Expected an error, didn't get one")
+				fmt.Println("Value was", value)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestParsePhoneNormalisesToE164(t *testing.T) {
+	numbers := []struct {
+		Value  string
+		Region string
+		E164   string
+		Type   string
+	}{
+		{"07700 900123", "GB", "+447700900123", PhoneMobile},
+		{"+44 (0)20 7946 0018", "", "", ""},
+		{"020 7946 0018", "GBR", "+442079460018", PhoneFixedLine},
+		{"(415) 555-2671", "US", "+14155552671", PhoneFixedLineOrMobile},
+		{"0049 1512 3456789", "", "+4915123456789", PhoneMobile},
+		{"8 800 555 35 35", "RU", "+78005553535", PhoneTollFree},
+	}
+
+	for _, n := range numbers {
+		p, err := ParsePhone(n.Value, n.Region)
+		if n.E164 == "" {
+			if err == nil {
+				fmt.Println("expected an error parsing", n.Value)
+				t.FailNow()
+			}
+			continue
+		}
+
+		if err != nil || p.E164() != n.E164 || p.Type != n.Type {
+			fmt.Printf("parsing %s: got %s (%s), %v; want %s (%s)\n", n.Value, p.E164(), p.Type, err, n.E164, n.Type)
+			t.FailNow()
+		}
+	}
+}
+
+func TestParsePhoneChoosesRegionForSharedCallingCodes(t *testing.T) {
+	numbers := map[string]PhoneNumber{
+		"+7 701 123 4567":  {"KZ", "7", "7011234567", PhoneMobile},
+		"+7 912 345 6789":  {"RU", "7", "9123456789", PhoneMobile},
+		"+1 416 555 0123":  {"CA", "1", "4165550123", PhoneFixedLineOrMobile},
+		"+1 415 555 2671":  {"US", "1", "4155552671", PhoneFixedLineOrMobile},
+		"+1 800 555 0123":  {"US", "1", "8005550123", PhoneTollFree},
+		"8 701 123 4567":   {},
+		"(416) 555-0123":   {"CA", "1", "4165550123", PhoneFixedLineOrMobile},
+		"+7 (727) 2581234": {"KZ", "7", "7272581234", PhoneFixedLine},
+	}
+
+	for value, want := range numbers {
+		region := ""
+		if value[0] != '+' {
+			region = "CA"
+		}
+
+		p, err := ParsePhone(value, region)
+		if want.Region == "" {
+			if err == nil {
+				fmt.Println("expected an error parsing", value, "got", p)
+				t.FailNow()
+			}
+			continue
+		}
+		if err != nil || p != want {
+			fmt.Printf("parsing %s: got %+v, %v; want %+v\n", value, p, err, want)
+			t.FailNow()
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "localhost?phone=%2B77011234567", nil)
+	r.ParseForm()
+	if err := Phone(r, "phone", Options{"types": []string{PhoneMobile}}); err != nil {
+		fmt.Println("expected a Kazakh mobile number to pass, got", err)
+		t.FailNow()
+	}
+}
+
+func TestPhoneOptionsMustBeKnown(t *testing.T) {
+	r, _ := http.NewRequest("GET", "localhost?phone=07700900123", nil)
+	r.ParseForm()
+
+	for _, o := range []Options{
+		{"region": "XX"},
+		{"region": 44},
+		{"region": "GB", "types": "mobile"},
+		{"region": "GB", "types": []string{"cell"}},
+	} {
+		if err := Phone(r, "phone", o); !errors.Is(err, ErrMisconfiguredRule) {
+			fmt.Printf("expected a configuration error for %v, got %v\n", o, err)
+			t.FailNow()
+		}
+		if _, err := Compile(Rule{Param: "phone", Check: Phone, Options: o}); !errors.Is(err, ErrMisconfiguredRule) {
+			fmt.Printf("expected Compile to reject %v, got %v\n", o, err)
+			t.FailNow()
+		}
+	}
+}
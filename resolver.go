@@ -0,0 +1,172 @@
+package validate
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Resolver looks up the DNS records used by the email rules. It is
+// satisfied by *net.Resolver.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// DefaultResolver is used by Validators that do not have their own
+// Resolver. It caches the results from net.DefaultResolver, so that
+// the same domain is not looked up for every request.
+var DefaultResolver Resolver = NewCachingResolver(net.DefaultResolver, 1024, 5*time.Minute, time.Minute)
+
+// resolverFor returns the Resolver that the Validator running the
+// rule was configured with.
+func resolverFor(ctx context.Context) Resolver {
+	if rr := runFor(ctx); rr != nil && rr.settings.Resolver != nil {
+		return rr.settings.Resolver
+	}
+
+	return DefaultResolver
+}
+
+// isNotFound reports whether err means that the records do not
+// exist, rather than that they could not be looked up.
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// CachingResolver wraps a Resolver with a fixed size cache. Results
+// are kept until their TTL expires, and the least recently used
+// results are evicted when the cache is full. Domains that do not
+// exist are cached for a separate negative TTL. Other errors, such
+// as timeouts, are never cached.
+type CachingResolver struct {
+	resolver    Resolver
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type cacheEntry struct {
+	key     string
+	mx      []*net.MX
+	ips     []net.IPAddr
+	err     error
+	expires time.Time
+}
+
+// NewCachingResolver creates a CachingResolver that holds up to size
+// results from the given Resolver.
+func NewCachingResolver(r Resolver, size int, ttl time.Duration, negativeTTL time.Duration) *CachingResolver {
+	return &CachingResolver{
+		resolver:    r,
+		size:        size,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+// LookupMX returns the MX records for name, from the cache if possible.
+func (c *CachingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if e, ok := c.get("mx:" + name); ok {
+		return e.mx, e.err
+	}
+
+	mx, err := c.resolver.LookupMX(ctx, name)
+	c.put(&cacheEntry{key: "mx:" + name, mx: mx, err: err})
+	return mx, err
+}
+
+// LookupIPAddr returns the IP addresses for host, from the cache if
+// possible.
+func (c *CachingResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if e, ok := c.get("ip:" + host); ok {
+		return e.ips, e.err
+	}
+
+	ips, err := c.resolver.LookupIPAddr(ctx, host)
+	c.put(&cacheEntry{key: "ip:" + host, ips: ips, err: err})
+	return ips, err
+}
+
+func (c *CachingResolver) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*cacheEntry)
+	if Now().After(e.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	return e, true
+}
+
+func (c *CachingResolver) put(e *cacheEntry) {
+	switch {
+	case e.err == nil:
+		e.expires = Now().Add(c.ttl)
+	case isNotFound(e.err):
+		e.expires = Now().Add(c.negativeTTL)
+	default:
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[e.key] = c.order.PushFront(e)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// StaticResolver is a Resolver that answers from fixed records. It
+// is intended for testing rules such as MXEmail without a network.
+// Names that are not in either map are reported as not found.
+type StaticResolver struct {
+	MX map[string][]*net.MX
+	IP map[string][]net.IPAddr
+}
+
+// LookupMX returns the MX records for name.
+func (s StaticResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	if mx, ok := s.MX[name]; ok {
+		return mx, nil
+	}
+
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+// LookupIPAddr returns the IP addresses for host.
+func (s StaticResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	if ips, ok := s.IP[host]; ok {
+		return ips, nil
+	}
+
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
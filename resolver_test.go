@@ -0,0 +1,158 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var testResolver = StaticResolver{
+	MX: map[string][]*net.MX{
+		"tomm.us":     {{Host: "mx2.tomm.us.", Pref: 20}, {Host: "mx1.tomm.us.", Pref: 10}},
+		"nomail.com":  {{Host: ".", Pref: 0}},
+		"example.org": {},
+	},
+	IP: map[string][]net.IPAddr{
+		"a-only.com": {{IP: net.ParseIP("192.0.2.1")}},
+	},
+}
+
+func TestMXEmailUsesValidatorResolver(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+
+	passes := []string{"me@tomm.us", "me@a-only.com"}
+	fails := []string{"juststring", "me@space@tomm.us", "me@invalid.domain", "me@nomail.com", "me@example.org", "me@[192.0.2.1]"}
+
+	for _, value := range passes {
+		r.Form.Set("email", value)
+		v := Make(r, Rule{Param: "email", Check: MXEmail, Options: nil})
+		v.Resolver = testResolver
+		if msgs, _ := v.Run(); len(msgs) > 0 {
+			fmt.Println("Got an error, expected none:", msgs["email"])
+			fmt.Println("Value was", value)
+			t.FailNow()
+		}
+	}
+
+	for _, value := range fails {
+		r.Form.Set("email", value)
+		v := Make(r, Rule{Param: "email", Check: MXEmail, Options: nil})
+		v.Resolver = testResolver
+		if msgs, _ := v.Run(); len(msgs) == 0 {
+			fmt.Println("Expected an error, didn't get one")
+			fmt.Println("Value was", value)
+			t.FailNow()
+		}
+	}
+}
+
+func TestMailHostsAreOrderedByPreference(t *testing.T) {
+	hosts, err := mailHosts(context.Background(), testResolver, "tomm.us")
+	if err != nil || len(hosts) != 2 || hosts[0] != "mx1.tomm.us" {
+		fmt.Println("expected hosts ordered by preference, got", hosts, err)
+		t.FailNow()
+	}
+}
+
+func TestMailHostsDoNotReorderResolverRecords(t *testing.T) {
+	mailHosts(context.Background(), testResolver, "tomm.us")
+	if testResolver.MX["tomm.us"][0].Pref != 20 {
+		fmt.Println("expected the resolver's records to be left in order")
+		t.FailNow()
+	}
+
+	// Run with -race to check that lookups of a cached domain can
+	// be made concurrently.
+	rsv := NewCachingResolver(testResolver, 10, time.Minute, time.Minute)
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			mailHosts(context.Background(), rsv, "tomm.us")
+		}()
+	}
+	<-done
+	<-done
+}
+
+// countingResolver counts the lookups that reach it.
+type countingResolver struct {
+	Resolver
+	lookups int
+	err     error
+}
+
+func (c *countingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	c.lookups++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.Resolver.LookupMX(ctx, name)
+}
+
+func TestCachingResolver(t *testing.T) {
+	defer func(now func() time.Time) { Now = now }(Now)
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	Now = func() time.Time { return now }
+
+	ctx := context.Background()
+	counter := &countingResolver{Resolver: testResolver}
+	cache := NewCachingResolver(counter, 2, time.Minute, time.Second)
+
+	cache.LookupMX(ctx, "tomm.us")
+	cache.LookupMX(ctx, "tomm.us")
+	if counter.lookups != 1 {
+		fmt.Println("expected a cached result, got", counter.lookups, "lookups")
+		t.FailNow()
+	}
+
+	// Domains that do not exist are cached for the negative TTL.
+	cache.LookupMX(ctx, "invalid.domain")
+	cache.LookupMX(ctx, "invalid.domain")
+	if counter.lookups != 2 {
+		fmt.Println("expected a negative cached result, got", counter.lookups, "lookups")
+		t.FailNow()
+	}
+
+	now = now.Add(2 * time.Second)
+	cache.LookupMX(ctx, "invalid.domain")
+	cache.LookupMX(ctx, "tomm.us")
+	if counter.lookups != 3 {
+		fmt.Println("expected only the negative result to expire, got", counter.lookups, "lookups")
+		t.FailNow()
+	}
+
+	// Adding a third domain evicts the least recently used.
+	cache.LookupMX(ctx, "nomail.com")
+	cache.LookupMX(ctx, "tomm.us")
+	if counter.lookups != 4 {
+		fmt.Println("expected the most recently used result to be kept, got", counter.lookups, "lookups")
+		t.FailNow()
+	}
+	cache.LookupMX(ctx, "invalid.domain")
+	if counter.lookups != 5 {
+		fmt.Println("expected the least recently used result to be evicted, got", counter.lookups, "lookups")
+		t.FailNow()
+	}
+
+	now = now.Add(2 * time.Minute)
+	cache.LookupMX(ctx, "tomm.us")
+	if counter.lookups != 6 {
+		fmt.Println("expected the result to expire, got", counter.lookups, "lookups")
+		t.FailNow()
+	}
+
+	// Temporary failures are never cached.
+	counter.err = errors.New("timeout")
+	cache.LookupMX(ctx, "example.org")
+	cache.LookupMX(ctx, "example.org")
+	if counter.lookups != 8 {
+		fmt.Println("expected errors not to be cached, got", counter.lookups, "lookups")
+		t.FailNow()
+	}
+}
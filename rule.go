@@ -1,14 +1,16 @@
 package validate
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -20,6 +22,22 @@ type Rule struct {
 	Check CheckFunc
 	// Options is a map that is passed to the check func.
 	Options Options
+	// Source is the part of the request that Param is read from.
+	// By default it is the request's Form.
+	Source Source
+	// Filters are applied, in order, to the parameter's values
+	// before this rule is checked. Other rules for the parameter
+	// are checked against the values that were sent, unless they
+	// have Filters of their own. Filtered, Validated and UpdateForm
+	// use the values from the last rule for the parameter that has
+	// Filters.
+	Filters []Filter
+	// Name is the name of the rule's check, such as `max_length`,
+	// which finds the SchemaFunc and OptionsFunc registered for it
+	// and is reported by RuleError. Rules for the built in
+	// CheckFuncs do not need one, and rules made from tags or rule
+	// set files are named by them.
+	Name string
 }
 
 // Options is a map of strings to values that can be used inside
@@ -33,9 +51,13 @@ type CheckFunc func(*http.Request, string, Options) error
 
 // Required returns an error if the parameter is not in the request.
 // Additional checks should be made to ensure it is not empty, etc.
-var Required CheckFunc = func(r *http.Request, param string, _ Options) error {
-	if _, exists := r.Form[param]; !exists {
-		return fmt.Errorf("%s is required", param)
+var Required CheckFunc = func(r *http.Request, param string, o Options) error {
+	return requiredField(r.Context(), requestField(r, param, o))
+}
+
+func requiredField(ctx context.Context, f Field) error {
+	if !f.Exists {
+		return fmt.Errorf("%s is required", f.Name)
 	}
 
 	return nil
@@ -43,24 +65,35 @@ var Required CheckFunc = func(r *http.Request, param string, _ Options) error {
 
 // Empty returns an error if the parameter is empty. That is, it
 // exists in the request, but is an empty string.
-var Empty CheckFunc = func(r *http.Request, param string, _ Options) error {
-	value := r.Form.Get(param)
+var Empty CheckFunc = func(r *http.Request, param string, o Options) error {
+	return emptyField(r.Context(), requestField(r, param, o))
+}
+
+func emptyField(ctx context.Context, f Field) error {
+	value := f.String()
 
 	if value == "" {
-		return fmt.Errorf("%s cannot be empty", param)
+		return fmt.Errorf("%s cannot be empty", f.Name)
 	}
 
 	return nil
 }
 
+var (
+	nonAlpha        = regexp.MustCompile(`[^a-zA-Z]`)
+	nonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9]`)
+)
+
 // Alpha returns an error if the parameter contains any characters
 // that are not in the alphabet, represented by the regular
 // expression `[a-zA-Z]+`.
-var Alpha CheckFunc = func(r *http.Request, param string, _ Options) error {
-	fail, _ := regexp.MatchString(`[^a-zA-Z]+`, r.Form.Get(param))
+var Alpha CheckFunc = func(r *http.Request, param string, o Options) error {
+	return alphaField(r.Context(), requestField(r, param, o))
+}
 
-	if fail {
-		return fmt.Errorf("%s must only contain alphabetical characters", param)
+func alphaField(ctx context.Context, f Field) error {
+	if nonAlpha.MatchString(f.String()) {
+		return fmt.Errorf("%s must only contain alphabetical characters", f.Name)
 	}
 
 	return nil
@@ -68,11 +101,13 @@ var Alpha CheckFunc = func(r *http.Request, param string, _ Options) error {
 
 // Alphanumeric returns an error if the parameter contains
 // any characters that are not letters or numbers.
-var Alphanumeric CheckFunc = func(r *http.Request, param string, _ Options) error {
-	fail, _ := regexp.MatchString(`[^a-zA-Z0-9]+`, r.Form.Get(param))
+var Alphanumeric CheckFunc = func(r *http.Request, param string, o Options) error {
+	return alphanumericField(r.Context(), requestField(r, param, o))
+}
 
-	if fail {
-		return fmt.Errorf("%s must only contain alphanumeric characters", param)
+func alphanumericField(ctx context.Context, f Field) error {
+	if nonAlphanumeric.MatchString(f.String()) {
+		return fmt.Errorf("%s must only contain alphanumeric characters", f.Name)
 	}
 
 	return nil
@@ -80,12 +115,17 @@ var Alphanumeric CheckFunc = func(r *http.Request, param string, _ Options) erro
 
 // Integer returns an error if the parameter cannot be converted
 // to an integer.
-var Integer CheckFunc = func(r *http.Request, param string, _ Options) error {
-	_, err := strconv.Atoi(r.Form.Get(param))
+var Integer CheckFunc = func(r *http.Request, param string, o Options) error {
+	return integerField(r.Context(), requestField(r, param, o))
+}
+
+func integerField(ctx context.Context, f Field) error {
+	i, err := strconv.Atoi(f.String())
 	if err != nil {
-		return fmt.Errorf("%s must be an integer", param)
+		return fmt.Errorf("%s must be an integer", f.Name)
 	}
 
+	setValue(ctx, f.Name, i)
 	return nil
 }
 
@@ -93,29 +133,38 @@ var Integer CheckFunc = func(r *http.Request, param string, _ Options) error {
 // that is not boolean. Because these values are coming in
 // via a HTTP request (and are therefore strings), a boolean
 // value must be inferred.
-var Boolean CheckFunc = func(r *http.Request, param string, _ Options) error {
-	value := r.Form.Get(param)
+var Boolean CheckFunc = func(r *http.Request, param string, o Options) error {
+	return booleanField(r.Context(), requestField(r, param, o))
+}
+
+func booleanField(ctx context.Context, f Field) error {
+	value := f.String()
 
 	if value == "true" || value == "false" || value == "1" || value == "0" {
+		setValue(ctx, f.Name, value == "true" || value == "1")
 		return nil
 	}
 
-	return fmt.Errorf("%s must be a boolean value", param)
+	return fmt.Errorf("%s must be a boolean value", f.Name)
 }
 
 // MaxLength returns an error if the parameter length (number
 // of characters) exceeds the length set in the Options map
 // passed to the Rule.
 var MaxLength CheckFunc = func(r *http.Request, param string, o Options) error {
-	value := r.Form.Get(param)
+	return maxLengthField(r.Context(), requestField(r, param, o))
+}
+
+func maxLengthField(ctx context.Context, f Field) error {
+	value := f.String()
 
-	max, ok := o["length"].(int)
+	max, ok := f.Options["length"].(int)
 	if !ok {
-		max = 0
+		return misconfigured(f.Name, "length must be an int")
 	}
 
 	if len(value) > max {
-		return fmt.Errorf("%s cannot be longer than %d characters", param, max)
+		return fmt.Errorf("%s cannot be longer than %d characters", f.Name, max)
 	}
 
 	return nil
@@ -125,240 +174,367 @@ var MaxLength CheckFunc = func(r *http.Request, param string, o Options) error {
 // of characters) is shorter than the length set in the Options
 // map passed to the Rule.
 var MinLength CheckFunc = func(r *http.Request, param string, o Options) error {
-	value := r.Form.Get(param)
+	return minLengthField(r.Context(), requestField(r, param, o))
+}
 
-	min, ok := o["length"].(int)
+func minLengthField(ctx context.Context, f Field) error {
+	value := f.String()
+
+	min, ok := f.Options["length"].(int)
 	if !ok {
-		min = 0
+		return misconfigured(f.Name, "length must be an int")
 	}
 
 	if len(value) < min {
-		return fmt.Errorf("%s must be longer than %d characters", param, min)
+		return fmt.Errorf("%s must be longer than %d characters", f.Name, min)
 	}
 
 	return nil
 }
 
 // Regex returns an error if the parameter does not satisfy
-// the regular expression passed in the Options map.
+// the regular expression passed in the Options map. The `pattern`
+// can be a string or a *regexp.Regexp.
 var Regex CheckFunc = func(r *http.Request, param string, o Options) error {
-	value := r.Form.Get(param)
+	return regexField(r.Context(), requestField(r, param, o))
+}
 
-	pattern, ok := o["pattern"].(string)
-	if !ok {
-		return fmt.Errorf("unable to create regex to validate %s parameter", param)
+func regexField(ctx context.Context, f Field) error {
+	value := f.String()
+
+	re, err := patternOption(f.Options)
+	if err != nil {
+		return misconfigured(f.Name, "%w", err)
 	}
 
-	if pass, _ := regexp.MatchString(pattern, value); !pass {
-		return fmt.Errorf("%s did not match regex `%s`", param, pattern)
+	if !re.MatchString(value) {
+		return fmt.Errorf("%s did not match regex `%s`", f.Name, re)
 	}
 
 	return nil
 }
 
 // NotRegex returns an error if the parameter value is satisfied
-// by the regular expression passed in the Options map.
+// by the regular expression passed in the Options map. The
+// `pattern` can be a string or a *regexp.Regexp.
 var NotRegex CheckFunc = func(r *http.Request, param string, o Options) error {
-	value := r.Form.Get(param)
+	return notRegexField(r.Context(), requestField(r, param, o))
+}
 
-	pattern, ok := o["pattern"].(string)
-	if !ok {
-		return fmt.Errorf("unable to create regex to validate %s parameter", param)
+func notRegexField(ctx context.Context, f Field) error {
+	value := f.String()
+
+	re, err := patternOption(f.Options)
+	if err != nil {
+		return misconfigured(f.Name, "%w", err)
 	}
 
-	if pass, _ := regexp.MatchString(pattern, value); pass {
-		return fmt.Errorf("%s must not match regex `%s`", param, pattern)
+	if re.MatchString(value) {
+		return fmt.Errorf("%s must not match regex `%s`", f.Name, re)
 	}
 
 	return nil
 }
 
-// MXEmail looks up the MX Records on a domain to check if a record exists. If
-// an MX record exists, it is likely that the email address is real. This is
-// smarter than just checking if an email address fits a certain format.
-var MXEmail CheckFunc = func(r *http.Request, param string, o Options) error {
-	if err := Email(r, param, nil); err != nil {
-		return err
+// maxPatterns is the number of string patterns that are cached.
+// Patterns compiled once the cache is full are not kept, so that
+// rules built from request data cannot grow it without bound.
+const maxPatterns = 1024
+
+// patterns caches the regular expressions that string patterns are
+// compiled to, so that each is only compiled once.
+var patterns = struct {
+	sync.RWMutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+// patternOption returns the `pattern` in the Options map, compiling
+// it if it is a string.
+func patternOption(o Options) (*regexp.Regexp, error) {
+	switch pattern := o["pattern"].(type) {
+	case *regexp.Regexp:
+		if pattern != nil {
+			return pattern, nil
+		}
+	case string:
+		return compilePattern(pattern)
 	}
 
-	timeout, ok := o["timeout"].(int)
-	if !ok {
-		timeout = 5
+	return nil, errors.New("pattern must be a string or *regexp.Regexp")
+}
+
+// compilePattern compiles a regular expression, or returns the one
+// that was compiled for the same pattern before. JSON Schemas use
+// their loader's cache instead, so that the patterns of schemas that
+// are no longer used are not kept.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patterns.RLock()
+	re, ok := patterns.m[pattern]
+	patterns.RUnlock()
+	if ok {
+		return re, nil
 	}
 
-	domain := getDomain(r.Form.Get(param))
-	records, err := getMXRecords(r.Context(), domain, timeout)
+	re, err := regexp.Compile(pattern)
 	if err != nil {
-		return fmt.Errorf("the host %s is not a valid email provider", domain)
+		return nil, err
 	}
 
-	if len(records) == 0 {
-		return fmt.Errorf("no MX records exist for %s", param)
+	patterns.Lock()
+	if len(patterns.m) < maxPatterns {
+		patterns.m[pattern] = re
 	}
+	patterns.Unlock()
 
-	return nil
+	return re, nil
 }
 
-// TelnetEmail uses a telnet connection to dial into the mail provider
-// for the given email address and uses this connection to verify if
-// an email address is valid.
+// MXEmail looks up the MX Records on a domain to check if a record exists. If
+// an MX record exists, it is likely that the email address is real. This is
+// smarter than just checking if an email address fits a certain format.
 //
-// TODO: Mixed results on Outlook/Hotmail.
-var TelnetEmail CheckFunc = func(r *http.Request, param string, _ Options) error {
-	if err := Email(r, param, nil); err != nil {
-		return err
-	}
-
-	address := r.Form.Get(param)
+// As per RFC 5321, a domain without MX records can still receive email if it
+// has an A or AAAA record. Domains that publish a null MX record (RFC 7505)
+// do not accept email, so are rejected. Lookups use the Validator's Resolver,
+// or DefaultResolver, and time out after the `timeout` in seconds in the
+// Options map, which defaults to 5. Addresses at an IP address literal, such
+// as `me@[192.0.2.1]`, have no domain to look up, so are rejected.
+var MXEmail CheckFunc = func(r *http.Request, param string, o Options) error {
+	return mxEmailField(r.Context(), requestField(r, param, o))
+}
 
-	domain := getDomain(address)
-	records, err := getMXRecords(r.Context(), domain, 5)
-	if err != nil || len(records) == 0 {
-		return fmt.Errorf("no MX records exist for %s", param)
+func mxEmailField(ctx context.Context, f Field) error {
+	if err := domainEmailField(ctx, f); err != nil {
+		return err
 	}
 
-	conn, err := net.Dial("tcp", records[0].Host+":25")
-	if err != nil {
-		return fmt.Errorf("unable to connect to %s to validate email", domain)
+	timeout, ok := f.Options["timeout"].(int)
+	if !ok {
+		timeout = 5
 	}
-	defer conn.Close()
-
-	responder := bufio.NewReader(conn)
-	// Discards the first line of the telnet connection
-	// so we are ready to send some commands to it.
-	responder.ReadString('\n')
 
-	commands := []string{
-		"helo hi",
-		"mail from: <validate@gostalt.com>",
-		fmt.Sprintf("rcpt to: <%s>", address),
-	}
+	domain := getDomain(f.String())
 
-	var msg string
-	for _, cmd := range commands {
-		fmt.Fprintf(conn, cmd+"\n")
-		msg, _ = responder.ReadString('\n')
-	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
 
-	if msg[0:3] != "250" {
-		return fmt.Errorf("%s is not a valid email address", address)
+	_, err := mailHosts(ctx, resolverFor(ctx), domain)
+	switch {
+	case err == errNullMX:
+		return fmt.Errorf("the host %s does not accept email", domain)
+	case err != nil:
+		return fmt.Errorf("the host %s is not a valid email provider", domain)
 	}
 
 	return nil
 }
 
-// Email returns an error if the parameter value is not a valid
-// email address.
-var Email CheckFunc = func(r *http.Request, param string, _ Options) error {
-	value := r.Form.Get(param)
+// TelnetEmail connects to the mail servers for the given email address over
+// SMTP, and asks whether they will accept mail for it without sending a
+// message. Servers are tried in order of MX preference.
+//
+// The Options map can set the `helo` name and `from` address sent to the
+// server, the `timeout` in seconds, which defaults to 10, and the `port`,
+// which defaults to 25. By default only addresses that a server accepts
+// pass. Passing `"allow_unknown": true` also passes addresses that could
+// not be checked or were greylisted, and `"reject_catch_all": true` fails
+// addresses on servers that accept mail for any recipient. Addresses at an
+// IP address literal are rejected, as they have no MX records.
+var TelnetEmail CheckFunc = func(r *http.Request, param string, o Options) error {
+	return telnetEmailField(r.Context(), requestField(r, param, o))
+}
+
+func telnetEmailField(ctx context.Context, f Field) error {
+	if err := domainEmailField(ctx, f); err != nil {
+		return err
+	}
 
-	atCount := strings.Count(value, "@")
+	address := f.String()
 
-	// If there is not one @ sign in the string, it is not
-	// a valid email address.
-	if atCount != 1 {
-		return fmt.Errorf("%s is not a valid email address", param)
+	timeout, ok := f.Options["timeout"].(int)
+	if !ok {
+		timeout = 10
 	}
 
-	if pass, _ := regexp.MatchString(`^[^@\s]+@[^@\s]+$`, value); pass {
+	v := &SMTPVerifier{Resolver: resolverFor(ctx), Timeout: time.Duration(timeout) * time.Second}
+	v.HeloName, _ = f.Options["helo"].(string)
+	v.MailFrom, _ = f.Options["from"].(string)
+	v.Port, _ = f.Options["port"].(string)
+
+	allowUnknown, _ := f.Options["allow_unknown"].(bool)
+	rejectCatchAll, _ := f.Options["reject_catch_all"].(bool)
+
+	result, err := v.Verify(ctx, address)
+	switch {
+	case result.Status == SMTPInvalid:
+		return fmt.Errorf("%s is not a valid email address", address)
+	case result.Status == SMTPValid && result.CatchAll && rejectCatchAll:
+		return fmt.Errorf("unable to verify %s is a valid email address", address)
+	case result.Status == SMTPValid:
 		return nil
+	case err != nil && !allowUnknown:
+		return fmt.Errorf("unable to connect to %s to validate email", getDomain(address))
+	case !allowUnknown:
+		return fmt.Errorf("unable to verify %s is a valid email address", address)
 	}
 
-	return fmt.Errorf("%s is not a valid email address", param)
+	return nil
 }
 
 // RFC3339 returns an error if the parameter does not satisfy
 // the RFC3339 format.
-var RFC3339 CheckFunc = func(r *http.Request, param string, _ Options) error {
-	return DateFormat(r, param, Options{"format": time.RFC3339})
+var RFC3339 CheckFunc = func(r *http.Request, param string, o Options) error {
+	return rfc3339Field(r.Context(), requestField(r, param, o))
+}
+
+func rfc3339Field(ctx context.Context, f Field) error {
+	f.Options = Options{"format": time.RFC3339}
+	return dateFormatField(ctx, f)
 }
 
 // RFC1123 returns an error if the parameter does not satisfy
 // the RFC1123 format.
-var RFC1123 CheckFunc = func(r *http.Request, param string, _ Options) error {
-	return DateFormat(r, param, Options{"format": time.RFC1123})
+var RFC1123 CheckFunc = func(r *http.Request, param string, o Options) error {
+	return rfc1123Field(r.Context(), requestField(r, param, o))
+}
+
+func rfc1123Field(ctx context.Context, f Field) error {
+	f.Options = Options{"format": time.RFC1123}
+	return dateFormatField(ctx, f)
 }
 
 // RFC822 returns an error if the parameter does not satisfy the
 // RFC822 format.
-var RFC822 CheckFunc = func(r *http.Request, param string, _ Options) error {
-	return DateFormat(r, param, Options{"format": time.RFC822})
+var RFC822 CheckFunc = func(r *http.Request, param string, o Options) error {
+	return rfc822Field(r.Context(), requestField(r, param, o))
+}
+
+func rfc822Field(ctx context.Context, f Field) error {
+	f.Options = Options{"format": time.RFC822}
+	return dateFormatField(ctx, f)
 }
 
 // UnixDate returns an error if the parameter does not satisfy
 // the format defined in Go's UnixDate const.
-var UnixDate CheckFunc = func(r *http.Request, param string, _ Options) error {
-	return DateFormat(r, param, Options{"format": time.UnixDate})
+var UnixDate CheckFunc = func(r *http.Request, param string, o Options) error {
+	return unixDateField(r.Context(), requestField(r, param, o))
+}
+
+func unixDateField(ctx context.Context, f Field) error {
+	f.Options = Options{"format": time.UnixDate}
+	return dateFormatField(ctx, f)
 }
 
 // DateFormat returns an error if the parameter does not
 // satisfy the date format passed in the Options struct.
 var DateFormat CheckFunc = func(r *http.Request, param string, o Options) error {
-	value := r.Form.Get(param)
+	return dateFormatField(r.Context(), requestField(r, param, o))
+}
 
-	format, ok := o["format"].(string)
+func dateFormatField(ctx context.Context, f Field) error {
+	value := f.String()
+
+	format, ok := f.Options["format"].(string)
 	if !ok {
-		return fmt.Errorf("unable to create date format string")
+		return misconfigured(f.Name, "format must be a string")
 	}
 
-	if _, err := time.Parse(format, value); err != nil {
-		return fmt.Errorf("%s does not satisfy date format %s", param, format)
+	t, err := time.Parse(format, value)
+	if err != nil {
+		return fmt.Errorf("%s does not satisfy date format %s", f.Name, format)
 	}
 
+	setValue(ctx, f.Name, t)
 	return nil
 }
 
 // Date is a comprehensive validator that returns an error if
 // the parameter does not satisfy any of Go's built-in date
-// formats.
+// formats, or an ISO 8601 date with or without a time.
 //
 // To validate against additional custom formats, you can pass
 // a slice of strings to the Options struct using a `formats` key.
 var Date CheckFunc = func(r *http.Request, param string, o Options) error {
-	formats := []string{
-		time.ANSIC,
-		time.UnixDate,
-		time.RubyDate,
-		time.RFC822,
-		time.RFC822Z,
-		time.RFC850,
-		time.RFC1123,
-		time.RFC1123Z,
-		time.RFC3339,
-		time.RFC3339Nano,
-
-		// TODO: These are times... Maybe move them?
-		time.Kitchen,
-		time.Stamp,
-		time.StampMilli,
-		time.StampMicro,
-		time.StampNano,
-	}
-
-	customFormats, exists := o["formats"]
-	if exists {
-		customFormats, ok := customFormats.([]string)
-		if !ok {
-			return fmt.Errorf("unable to create date format string")
+	return dateField(r.Context(), requestField(r, param, o))
+}
+
+func dateField(ctx context.Context, f Field) error {
+	if _, ok := f.Options["formats"]; ok {
+		if _, ok := f.Options["formats"].([]string); !ok {
+			return misconfigured(f.Name, "formats must be a []string")
 		}
+	}
+
+	if _, err := paramDate(ctx, f); err != nil {
+		return fmt.Errorf("%s does not satisfy and date format", f.Name)
+	}
+
+	return nil
+}
+
+// dateFormats are the layouts that Date accepts.
+var dateFormats = []string{
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+	time.RFC822,
+	time.RFC822Z,
+	time.RFC850,
+	time.RFC1123,
+	time.RFC1123Z,
+	time.RFC3339,
+	time.RFC3339Nano,
+
+	// These are times rather than dates, and are only kept for
+	// backwards compatibility. TimeOnly validates times alone.
+	time.Kitchen,
+	time.Stamp,
+	time.StampMilli,
+	time.StampMicro,
+	time.StampNano,
+}
 
-		formats = append(formats, customFormats...)
+// errNullMX is returned by mailHosts for domains that publish a
+// null MX record to show that they do not accept email.
+const errNullMX Error = "domain does not accept email"
+
+// mailHosts returns the hosts that accept email for a domain, in
+// order of preference. If the domain has no MX records, the domain
+// itself is returned as long as it has an address record.
+func mailHosts(ctx context.Context, rsv Resolver, domain string) ([]string, error) {
+	records, err := rsv.LookupMX(ctx, domain)
+	if err != nil && !isNotFound(err) {
+		return nil, err
 	}
 
-	for _, format := range formats {
-		if err := DateFormat(r, param, Options{"format": format}); err == nil {
-			return nil
+	if len(records) == 0 {
+		ips, err := rsv.LookupIPAddr(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("no mail hosts exist for %s", domain)
 		}
+		return []string{domain}, nil
 	}
 
-	return fmt.Errorf("%s does not satisfy and date format", param)
-}
+	if len(records) == 1 && (records[0].Host == "." || records[0].Host == "") {
+		return nil, errNullMX
+	}
 
-func getMXRecords(ctx context.Context, domain string, timeout int) ([]*net.MX, error) {
-	rsv := net.Resolver{}
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
-	defer cancel()
-	return rsv.LookupMX(ctx, domain)
+	// Resolvers such as CachingResolver return the same slice to
+	// every caller, so it is copied before being sorted.
+	records = append([]*net.MX(nil), records...)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Pref < records[j].Pref
+	})
+
+	hosts := make([]string, 0, len(records))
+	for _, mx := range records {
+		hosts = append(hosts, strings.TrimSuffix(mx.Host, "."))
+	}
+
+	return hosts, nil
 }
 
 func getDomain(email string) string {
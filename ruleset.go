@@ -0,0 +1,393 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	checksMu sync.RWMutex
+	checks   = map[string]CheckFunc{
+		"between":          Between,
+		"unix_date":        UnixDate,
+		"date_format":      DateFormat,
+		"telnet_email":     TelnetEmail,
+		"required_file":    RequiredFile,
+		"max_file_size":    MaxFileSize,
+		"min_file_size":    MinFileSize,
+		"max_files":        MaxFiles,
+		"file_extension":   FileExtension,
+		"mime_type":        MimeType,
+		"image":            Image,
+		"image_dimensions": ImageDimensions,
+		"aspect_ratio":     AspectRatio,
+		"zip":              Zip,
+		"pdf":              PDF,
+		"rfc1123":          RFC1123,
+		"rfc822":           RFC822,
+		"weekday":          Weekday,
+	}
+
+	filters = map[string]Filter{
+		"trim":              Trim,
+		"lower":             Lower,
+		"upper":             Upper,
+		"collapse_spaces":   CollapseSpaces,
+		"strip_spaces":      StripSpaces,
+		"strip_tags":        StripTags,
+		"normalize_unicode": NormalizeUnicode,
+	}
+)
+
+// builtinNames are the names of the built in CheckFuncs, by their
+// funcID, so that rules that use them are named without a Name.
+// Each is a separate function literal, so no two share an ID, and
+// registered CheckFuncs are never added, as closures made by the
+// same literal would share a name.
+var builtinNames = make(map[uintptr]string)
+
+func init() {
+	for name, tr := range tagRules {
+		checks[name] = tr.Check
+	}
+	for name, check := range checks {
+		if other, ok := builtinNames[funcID(check)]; ok {
+			panic("validate: " + name + " and " + other + " share a CheckFunc")
+		}
+		if _, ok := fieldFuncs[name]; !ok {
+			panic("validate: " + name + " has no FieldFunc")
+		}
+		builtinNames[funcID(check)] = name
+	}
+}
+
+// funcID identifies a CheckFunc by its code.
+func funcID(check CheckFunc) uintptr {
+	return reflect.ValueOf(check).Pointer()
+}
+
+// ruleName returns the Rule's Name or, if it does not have one and
+// uses a built in CheckFunc, the name of the CheckFunc.
+func ruleName(rule Rule) string {
+	if rule.Name != "" || rule.Check == nil {
+		return rule.Name
+	}
+
+	return builtinNames[funcID(rule.Check)]
+}
+
+// RegisterCheck makes a CheckFunc available to rule set files under
+// the given name. Built in rules are named in snake case, such as
+// `max_length`, and registering one of their names replaces it.
+// Rules read from files are given the name as their Name, so they
+// use the SchemaFunc and OptionsFunc registered under it.
+func RegisterCheck(name string, check CheckFunc) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+
+	checks[name] = check
+}
+
+func checkNamed(name string) (CheckFunc, bool) {
+	checksMu.RLock()
+	defer checksMu.RUnlock()
+
+	check, ok := checks[name]
+	return check, ok
+}
+
+// ParseRuleSets parses rule sets from a JSON, YAML or TOML document,
+// given by format. The document maps the name of each set, such as a
+// route or form, to a list of rules:
+//
+//	signup:
+//	  - param: email
+//	    check: required
+//	  - param: bio
+//	    check: max_length
+//	    options: {length: 500}
+//	    filters: [trim]
+//	  - param: page
+//	    source: query
+//	    check: integer
+//
+// A rule's check is the name of a built in or registered CheckFunc,
+// see RegisterCheck, and its filters are the snake case names of the
+// built in Filters. Whole numbers in options are ints, and lists of
+// strings are []string. Dates and times are strings, as they were
+// written. Problems are reported as a *ConfigError with the line that
+// they were found on, except for TOML files, where only syntax errors
+// have a line.
+//
+// YAML scalars follow the YAML 1.2 core schema, so `yes` is a string,
+// and anchors and `<<` merge keys can be used to share options.
+func ParseRuleSets(data []byte, format string) (map[string][]Rule, error) {
+	root, err := parseConfig(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	sets, ok := root.Value.(map[string]*configNode)
+	if !ok && root.Value != nil {
+		return nil, configErrorf(root.Line, "expected a map of rule set names to rules")
+	}
+
+	rules := make(map[string][]Rule, len(sets))
+	for _, name := range configKeys(sets) {
+		node := sets[name]
+		items, ok := node.Value.([]*configNode)
+		if !ok {
+			return nil, configErrorf(node.Line, "rule set %q must be a list of rules", name)
+		}
+
+		for _, item := range items {
+			rule, err := configRule(item)
+			if err != nil {
+				return nil, err
+			}
+			rules[name] = append(rules[name], rule)
+		}
+	}
+
+	return rules, nil
+}
+
+func configKeys(m map[string]*configNode) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return m[keys[i]].Line < m[keys[j]].Line || m[keys[i]].Line == m[keys[j]].Line && keys[i] < keys[j]
+	})
+
+	return keys
+}
+
+func configRule(node *configNode) (Rule, error) {
+	var rule Rule
+
+	fields, ok := node.Value.(map[string]*configNode)
+	if !ok {
+		return rule, configErrorf(node.Line, "expected a rule with a param and a check")
+	}
+
+	for _, key := range configKeys(fields) {
+		field := fields[key]
+		switch key {
+		case "param":
+			param, ok := field.Value.(string)
+			if !ok {
+				return rule, configErrorf(field.Line, "param must be a string")
+			}
+			rule.Param = param
+		case "source":
+			source, _ := field.Value.(string)
+			rule.Source = Source(source)
+			if source == "form" {
+				rule.Source = Form
+			}
+			if !rule.Source.valid() {
+				return rule, configErrorf(field.Line, "unknown source %v", field.Value)
+			}
+		case "check":
+			name, _ := field.Value.(string)
+			check, ok := checkNamed(name)
+			if !ok {
+				return rule, configErrorf(field.Line, "unknown check %v", field.Value)
+			}
+			rule.Check, rule.Name = check, name
+		case "options":
+			options, ok := configValue(field).(map[string]interface{})
+			if !ok {
+				return rule, configErrorf(field.Line, "options must be a map")
+			}
+			rule.Options = options
+		case "filters":
+			names, ok := configValue(field).([]string)
+			if !ok {
+				return rule, configErrorf(field.Line, "filters must be a list of names")
+			}
+			for _, name := range names {
+				f, ok := filters[name]
+				if !ok {
+					return rule, configErrorf(field.Line, "unknown filter %s", name)
+				}
+				rule.Filters = append(rule.Filters, f)
+			}
+		default:
+			return rule, configErrorf(field.Line, "unknown field %q", key)
+		}
+	}
+
+	if rule.Check == nil {
+		return rule, configErrorf(node.Line, "rule must have a check")
+	}
+	if rule.Param == "" && (rule.Source == Form || rule.Source == Body) {
+		return rule, configErrorf(node.Line, "rule must have a param")
+	}
+
+	return rule, nil
+}
+
+// configValue converts a node to the values used in Options.
+func configValue(node *configNode) interface{} {
+	switch v := node.Value.(type) {
+	case map[string]*configNode:
+		m := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			m[key] = configValue(child)
+		}
+		return m
+	case []*configNode:
+		values := make([]interface{}, len(v))
+		strs := make([]string, len(v))
+		allStrings := true
+		for i, child := range v {
+			values[i] = configValue(child)
+			s, ok := values[i].(string)
+			strs[i], allStrings = s, allStrings && ok
+		}
+		if allStrings {
+			return strs
+		}
+		return values
+	}
+
+	return node.Value
+}
+
+// RuleSets holds the rule sets read from a file, and can reload them
+// while requests are being validated. The sets are replaced
+// atomically, so a Validator always sees either the old rules or the
+// new ones, and a file that cannot be loaded leaves the old rules in
+// place.
+type RuleSets struct {
+	file  string
+	sets  atomic.Pointer[map[string][]Rule]
+	mu    sync.Mutex
+	mtime time.Time
+}
+
+// LoadRuleSets reads rule sets from a file. The format is given by
+// its extension: `.json`, `.yaml`, `.yml` or `.toml`. See
+// ParseRuleSets.
+func LoadRuleSets(file string) (*RuleSets, error) {
+	s := &RuleSets{file: file}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reload reads the file again, and replaces the rule sets if it is
+// valid.
+func (s *RuleSets) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.file)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return err
+	}
+
+	sets, err := ParseRuleSets(data, filepath.Ext(s.file))
+	if ce, ok := err.(*ConfigError); ok {
+		ce.File = s.file
+	}
+	if err != nil {
+		return err
+	}
+
+	s.sets.Store(&sets)
+	s.mtime = info.ModTime()
+	return nil
+}
+
+// Watch checks the file for changes every interval, and reloads it
+// when it has been modified, until ctx is done. Errors from reloading
+// are passed to onError, which can be nil.
+func (s *RuleSets) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(s.file)
+		if err == nil {
+			s.mu.Lock()
+			changed := !info.ModTime().Equal(s.mtime)
+			s.mu.Unlock()
+			if !changed {
+				continue
+			}
+			err = s.Reload()
+		}
+
+		if err != nil {
+			// Only report a broken file once, until it changes.
+			s.mu.Lock()
+			if info != nil {
+				s.mtime = info.ModTime()
+			}
+			s.mu.Unlock()
+
+			if onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Names returns the names of the rule sets, sorted.
+func (s *RuleSets) Names() []string {
+	sets := *s.sets.Load()
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Rules returns a copy of the named rule set, or nil if there is no
+// set with that name.
+func (s *RuleSets) Rules(name string) []Rule {
+	rules := (*s.sets.Load())[name]
+	if rules == nil {
+		return nil
+	}
+
+	return append([]Rule(nil), rules...)
+}
+
+// Validator creates a Validator with the named rule set. It returns
+// an error if there is no set with that name.
+func (s *RuleSets) Validator(r *http.Request, name string) (*Validator, error) {
+	rules := s.Rules(name)
+	if rules == nil {
+		return nil, fmt.Errorf("rule set %q does not exist in %s", name, s.file)
+	}
+
+	return Make(r, rules...), nil
+}
@@ -0,0 +1,185 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var ruleSetDocs = map[string]string{
+	"json": `{
+		"signup": [
+			{"param": "email", "check": "required"},
+			{"param": "bio", "check": "max_length", "options": {"length": 5}, "filters": ["trim"]},
+			{"param": "role", "check": "in", "options": {"values": ["admin", "editor"]}},
+			{"param": "page", "source": "query", "check": "integer"}
+		]
+	}`,
+	"yaml": `
+signup:
+  - param: email
+    check: required
+  - param: bio
+    check: max_length
+    options: {length: 5}
+    filters: [trim]
+  - param: role
+    check: in
+    options:
+      values: [admin, editor]
+  - param: page
+    source: query
+    check: integer
+`,
+	"toml": `
+[[signup]]
+param = "email"
+check = "required"
+
+[[signup]]
+param = "bio"
+check = "max_length"
+options = { length = 5 }
+filters = ["trim"]
+
+[[signup]]
+param = "role"
+check = "in"
+[signup.options]
+values = ["admin", "editor"]
+
+[[signup]]
+param = "page"
+source = "query"
+check = "integer"
+`,
+}
+
+func TestParseRuleSets(t *testing.T) {
+	for format, doc := range ruleSetDocs {
+		sets, err := ParseRuleSets([]byte(doc), format)
+		if err != nil {
+			fmt.Println(format, "unexpected error:", err)
+			t.FailNow()
+		}
+
+		r, _ := http.NewRequest("POST", "localhost?page=x", strings.NewReader("bio=+hello+&role=owner"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		msgs, _ := Check(r, sets["signup"]...)
+		if len(msgs) != 3 || len(msgs["email"]) == 0 || len(msgs["role"]) == 0 || len(msgs["query.page"]) == 0 {
+			fmt.Println(format, "unexpected errors:", msgs)
+			t.FailNow()
+		}
+	}
+}
+
+func TestParseRuleSetsErrors(t *testing.T) {
+	cases := map[string]int{
+		"signup:\n  - param: email\n    check: requried\n":                       3,
+		"signup:\n  - param: email\n    check: required\n    filters: [tirm]\n":  4,
+		"signup:\n  - param: email\n    source: fragment\n    check: required\n": 3,
+		"signup:\n  - param: email\n    chek: required\n":                        3,
+		"signup:\n  - param: email\n":                                            2,
+		"signup:\n  param: email\n":                                              2,
+	}
+
+	for doc, line := range cases {
+		_, err := ParseRuleSets([]byte(doc), "yaml")
+		var ce *ConfigError
+		if !errors.As(err, &ce) || ce.Line != line {
+			fmt.Printf("%q: expected an error on line %d, got %v\n", doc, line, err)
+			t.FailNow()
+		}
+	}
+
+	_, err := ParseRuleSets([]byte("[[signup]]\nparam = \"email\"\ncheck = \"requried\"\n"), "toml")
+	if err == nil || err.Error() != "unknown check requried" {
+		fmt.Println("unexpected error:", err)
+		t.FailNow()
+	}
+}
+
+func TestRegisterCheck(t *testing.T) {
+	RegisterCheck("even", func(r *http.Request, param string, _ Options) error {
+		if len(r.Form.Get(param))%2 != 0 {
+			return fmt.Errorf("%s must have an even length", param)
+		}
+		return nil
+	})
+
+	sets, err := ParseRuleSets([]byte(`{"form": [{"param": "code", "check": "even"}]}`), "json")
+	if err != nil {
+		fmt.Println("unexpected error:", err)
+		t.FailNow()
+	}
+
+	r, _ := http.NewRequest("GET", "localhost?code=abc", nil)
+	if msgs, _ := Check(r, sets["form"]...); len(msgs["code"]) == 0 {
+		fmt.Println("expected the registered check to run, got", msgs)
+		t.FailNow()
+	}
+}
+
+func TestRuleSetsReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	os.WriteFile(file, []byte("signup:\n  - param: bio\n    check: max_length\n    options: {length: 5}\n"), 0o644)
+
+	sets, err := LoadRuleSets(file)
+	if err != nil {
+		fmt.Println("unexpected error:", err)
+		t.FailNow()
+	}
+
+	r, _ := http.NewRequest("GET", "localhost?bio=too+long", nil)
+	v, _ := sets.Validator(r, "signup")
+	if msgs, _ := v.Run(); len(msgs["bio"]) == 0 {
+		fmt.Println("expected the bio to be too long, got", msgs)
+		t.FailNow()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 1)
+	go sets.Watch(ctx, 10*time.Millisecond, func(err error) { errs <- err })
+
+	// A broken file is reported, and the old rules are kept.
+	os.WriteFile(file, []byte("signup:\n  - param: bio\n    check: max_lenght\n"), 0o644)
+	os.Chtimes(file, time.Now(), time.Now().Add(time.Second))
+	select {
+	case err := <-errs:
+		if !strings.HasPrefix(err.Error(), file+":3: ") {
+			fmt.Println("unexpected error:", err)
+			t.FailNow()
+		}
+	case <-time.After(time.Second):
+		fmt.Println("expected the broken file to be reported")
+		t.FailNow()
+	}
+	if rules := sets.Rules("signup"); len(rules) != 1 || rules[0].Options["length"] != 5 {
+		fmt.Println("expected the old rules to be kept, got", rules)
+		t.FailNow()
+	}
+
+	os.WriteFile(file, []byte("signup:\n  - param: bio\n    check: max_length\n    options: {length: 500}\n"), 0o644)
+	os.Chtimes(file, time.Now(), time.Now().Add(2*time.Second))
+	deadline := time.Now().Add(time.Second)
+	for sets.Rules("signup")[0].Options["length"] != 500 {
+		if time.Now().After(deadline) {
+			fmt.Println("expected the rules to be reloaded")
+			t.FailNow()
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := sets.Validator(r, "login"); err == nil {
+		fmt.Println("expected an error for an unknown rule set")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,126 @@
+package validate
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// run holds the state of a single call to Run or Validate. The
+// Validator's fields are only read while its rules are checked, so
+// that the same Validator can be run again, or from several
+// goroutines at once by Validate.
+type run struct {
+	// ctx is the context of the call to Run or Validate.
+	ctx context.Context
+	// settings is the Validator being run, which is read for its
+	// Resolver, domain lists and limits.
+	settings *Validator
+	// request is the request being validated. Its Form is the one
+	// that was sent, rather than the values of a Rule's Source. It is
+	// nil for Validate until httpRequest is called.
+	request *http.Request
+	// raw holds the original value of each field passed to
+	// Validate, by its name.
+	raw map[string]interface{}
+	// listed are the names of the fields passed to Validate that
+	// were slices.
+	listed map[string]bool
+	// sent are the values from each Source as they were sent.
+	sent map[Source]url.Values
+	// values are the values from each Source, after the rules'
+	// Filters have been applied.
+	values map[Source]url.Values
+	// lists are the keys of the parameters that were given as a
+	// list.
+	lists map[string]bool
+	// parsed holds the values that rules parsed parameters as.
+	parsed map[string]interface{}
+}
+
+// ruleRun is the state given to the CheckFunc of one Rule.
+type ruleRun struct {
+	*run
+	source Source
+}
+
+const runKey contextKey = "run"
+
+// runFor returns the state of the run that a rule is being checked
+// by, or nil if the rule was called directly.
+func runFor(ctx context.Context) *ruleRun {
+	rr, _ := ctx.Value(runKey).(*ruleRun)
+	return rr
+}
+
+// httpRequest returns the request being validated. Validate has no
+// request, so one whose Form holds the data is made the first time
+// that a rule needs it, such as a CheckFunc that is not built in.
+func (rn *run) httpRequest() *http.Request {
+	if rn.request == nil {
+		form := rn.sent[Form]
+		rn.request = (&http.Request{
+			Method:     http.MethodPost,
+			URL:        &url.URL{Path: "/"},
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+			Form:       form,
+			PostForm:   form,
+		}).WithContext(rn.ctx)
+	}
+
+	return rn.request
+}
+
+// field returns the Field that a built in rule checks, from the
+// values of the rule's Source.
+func (rn *run) field(rule Rule, form url.Values) Field {
+	values, exists := form[rule.Param]
+	f := Field{Name: rule.Param, Values: values, Exists: exists, Options: rule.Options, Data: form}
+
+	if rn.raw != nil && rule.Source == Form {
+		f.Value = rn.raw[rule.Param]
+	}
+	if f.Value == nil && len(values) > 0 {
+		f.Value = values[0]
+	}
+	if rn.request != nil && (rule.Source == Form || rule.Source == Body) {
+		f.Files = files(rn.request, rule.Param)
+	}
+
+	return f
+}
+
+// lookup returns the values of another field for rules that compare
+// fields, such as PostalCode's `field`. The name is the key that the
+// field is reported under, so `country` is read from the Form and
+// `query.country` from the URL's query, whichever Source the rule
+// itself reads. Outside of a run, the Field's Data is used.
+func lookup(ctx context.Context, f Field, name string) []string {
+	rr := runFor(ctx)
+	if rr == nil {
+		return f.Data[name]
+	}
+
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		if values, ok := rr.values[Source(name[:i])]; ok && Source(name[:i]) != Form {
+			return values[name[i+1:]]
+		}
+	}
+
+	return rr.values[Form][name]
+}
+
+// originalRequest returns the request being validated, rather than
+// the one that a rule's CheckFunc is given, or r outside of a run.
+func originalRequest(ctx context.Context, r *http.Request) *http.Request {
+	if rr := runFor(ctx); rr != nil {
+		return rr.httpRequest()
+	}
+
+	return r
+}
@@ -0,0 +1,267 @@
+package validate
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Schema is a JSON Schema (draft 2020-12), in the form that it is
+// marshalled to JSON. OpenAPI 3.1 uses the same schemas.
+type Schema map[string]interface{}
+
+// SchemaFunc returns the part of a parameter's Schema that a
+// CheckFunc enforces, given the Rule's Options, such as
+// `{"maxLength": 10}` for MaxLength.
+type SchemaFunc func(o Options) Schema
+
+var (
+	schemaMu    sync.RWMutex
+	schemaFuncs = make(map[string]SchemaFunc)
+)
+
+// RegisterSchema sets the SchemaFunc used to describe rules with the
+// given name. Built in rules are registered under their snake case
+// names, such as `max_length`. Other rules are described by setting
+// their Name, or by registering their CheckFunc with RegisterCheck
+// and reading them from a rule set file.
+func RegisterSchema(name string, fn SchemaFunc) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+
+	schemaFuncs[name] = fn
+}
+
+// ruleSchema returns the Schema fragment for a Rule, or nil if its
+// name does not have one.
+func ruleSchema(rule Rule) Schema {
+	name := ruleName(rule)
+	if name == "" {
+		return nil
+	}
+
+	schemaMu.RLock()
+	fn, ok := schemaFuncs[name]
+	schemaMu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	return fn(rule.Options)
+}
+
+// isRequired reports whether a Rule makes its parameter required.
+func isRequired(rule Rule) bool {
+	name := ruleName(rule)
+	return name == "required" || name == "required_file"
+}
+
+func init() {
+	fixed := func(s Schema) SchemaFunc {
+		return func(Options) Schema { return s }
+	}
+	option := func(key string, option string) SchemaFunc {
+		return func(o Options) Schema {
+			if value, ok := o[option]; ok {
+				return Schema{key: value}
+			}
+			return nil
+		}
+	}
+	format := func(f string) SchemaFunc {
+		return fixed(Schema{"type": "string", "format": f})
+	}
+	file := fixed(Schema{"type": "string", "contentMediaType": "application/octet-stream"})
+	enum := func(negate bool) SchemaFunc {
+		return func(o Options) Schema {
+			values, ok := o["values"].([]string)
+			if !ok {
+				return nil
+			}
+			if negate {
+				return Schema{"not": Schema{"enum": values}}
+			}
+			return Schema{"enum": values}
+		}
+	}
+
+	builtin := map[string]SchemaFunc{
+		"empty":        fixed(Schema{"type": "string", "minLength": 1}),
+		"alpha":        fixed(Schema{"type": "string", "pattern": "^[a-zA-Z]*$"}),
+		"alphanumeric": fixed(Schema{"type": "string", "pattern": "^[a-zA-Z0-9]*$"}),
+		"integer":      fixed(Schema{"type": "integer"}),
+		"boolean":      fixed(Schema{"type": "boolean"}),
+		"max_length":   option("maxLength", "length"),
+		"min_length":   option("minLength", "length"),
+		"regex": func(o Options) Schema {
+			if re, err := patternOption(o); err == nil {
+				return Schema{"pattern": re.String()}
+			}
+			return nil
+		},
+		"not_regex": func(o Options) Schema {
+			if re, err := patternOption(o); err == nil {
+				return Schema{"not": Schema{"pattern": re.String()}}
+			}
+			return nil
+		},
+		"email": func(o Options) Schema {
+			if o["mode"] == EmailIntl {
+				return Schema{"type": "string", "format": "idn-email"}
+			}
+			return Schema{"type": "string", "format": "email"}
+		},
+		"mx_email":         format("email"),
+		"telnet_email":     format("email"),
+		"disposable_email": format("email"),
+		"rfc3339":          format("date-time"),
+		"date_time":        format("date-time"),
+		"date_only":        format("date"),
+		"time_only":        format("time"),
+		"duration":         format("duration"),
+		"country": func(o Options) Schema {
+			if o["format"] == "alpha3" {
+				return Schema{"type": "string", "pattern": "^[A-Za-z]{3}$"}
+			}
+			return Schema{"type": "string", "pattern": "^[A-Za-z]{2}$"}
+		},
+		"in":             enum(false),
+		"not_in":         enum(true),
+		"list":           fixed(Schema{"type": "array"}),
+		"min_items":      option("minItems", "count"),
+		"max_items":      option("maxItems", "count"),
+		"distinct":       fixed(Schema{"uniqueItems": true}),
+		"required_file":  file,
+		"max_file_size":  file,
+		"min_file_size":  file,
+		"file_extension": file,
+		"mime_type": func(o Options) Schema {
+			if types, ok := o["types"].([]string); ok && len(types) == 1 {
+				return Schema{"type": "string", "contentMediaType": types[0]}
+			}
+			return Schema{"type": "string", "contentMediaType": "application/octet-stream"}
+		},
+		"image":            file,
+		"image_dimensions": file,
+		"aspect_ratio":     file,
+		"zip":              fixed(Schema{"type": "string", "contentMediaType": "application/zip"}),
+		"pdf":              fixed(Schema{"type": "string", "contentMediaType": "application/pdf"}),
+	}
+
+	for name, fn := range builtin {
+		RegisterSchema(name, fn)
+	}
+}
+
+// SchemaFor returns an object Schema describing the parameters of
+// the rules that are read from the request's Form or Body. Dotted
+// parameters, such as `address.city`, are described as nested
+// objects, and `*` or numeric segments as arrays. Parameters with a
+// Required or RequiredFile rule are listed as required.
+//
+// Each rule contributes the Schema registered for its name with
+// RegisterSchema. The built-in rules are registered already, and
+// rules without a Schema, such as those using Each, only add their
+// parameter.
+func SchemaFor(rules ...Rule) Schema {
+	root := Schema{"type": "object", "properties": Schema{}}
+
+	for _, rule := range rules {
+		if rule.Source != Form && rule.Source != Body {
+			continue
+		}
+
+		parent, name := root, ""
+		path := fieldPath(rule.Param)
+		for i, segment := range path {
+			if i == len(path)-1 {
+				name = segment
+				break
+			}
+			parent = childSchema(parent, segment, path[i+1])
+		}
+		if name == "" {
+			continue
+		}
+
+		if isIndex(name) {
+			parent["type"] = "array"
+			parent = ensureSchema(parent, "items")
+		} else {
+			object := parent
+			parent = ensureSchema(ensureSchema(object, "properties"), name)
+			if isRequired(rule) {
+				addRequired(object, name)
+			}
+		}
+
+		for k, v := range ruleSchema(rule) {
+			parent[k] = v
+		}
+	}
+
+	return root
+}
+
+// Schema returns a Schema describing the Validator's rules. See
+// SchemaFor.
+func (v *Validator) Schema() Schema {
+	return SchemaFor(v.Rules...)
+}
+
+// childSchema returns the Schema for a segment of a path, creating
+// it if needed. The segment is an array item if it is `*` or a
+// number, otherwise it is a property. The next segment determines
+// whether the child is an object or an array.
+func childSchema(parent Schema, segment string, next string) Schema {
+	var child Schema
+	if isIndex(segment) {
+		parent["type"] = "array"
+		child = ensureSchema(parent, "items")
+	} else {
+		if _, ok := parent["type"]; !ok {
+			parent["type"] = "object"
+		}
+		child = ensureSchema(ensureSchema(parent, "properties"), segment)
+	}
+
+	if _, ok := child["type"]; !ok {
+		if isIndex(next) {
+			child["type"] = "array"
+		} else {
+			child["type"] = "object"
+		}
+	}
+
+	return child
+}
+
+func ensureSchema(s Schema, key string) Schema {
+	if child, ok := s[key].(Schema); ok {
+		return child
+	}
+
+	child := Schema{}
+	s[key] = child
+	return child
+}
+
+// addRequired adds a property to an object's `required` list.
+func addRequired(object Schema, name string) {
+	required, _ := object["required"].([]string)
+	for _, r := range required {
+		if r == name {
+			return
+		}
+	}
+
+	object["required"] = append(required, name)
+}
+
+func isIndex(segment string) bool {
+	if segment == "*" {
+		return true
+	}
+
+	_, err := strconv.Atoi(segment)
+	return err == nil
+}
@@ -0,0 +1,132 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// schemaJSON marshals a value so that it can be compared with the
+// JSON that is expected.
+func schemaJSON(t *testing.T, v interface{}, want string) {
+	got, err := json.Marshal(v)
+	if err != nil {
+		fmt.Println("unable to marshal the schema:", err)
+		t.FailNow()
+	}
+
+	// Round trip both through maps, so that keys are sorted.
+	var g, w interface{}
+	json.Unmarshal(got, &g)
+	json.Unmarshal([]byte(want), &w)
+	got, _ = json.Marshal(g)
+	wantJSON, _ := json.Marshal(w)
+
+	if string(got) != string(wantJSON) {
+		fmt.Println("got ", string(got))
+		fmt.Println("want", string(wantJSON))
+		t.FailNow()
+	}
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema := SchemaFor(
+		Rule{Param: "email", Check: Required},
+		Rule{Param: "email", Check: Email},
+		Rule{Param: "email", Check: MaxLength, Options: Options{"length": 255}},
+		Rule{Param: "age", Check: Integer},
+		Rule{Param: "code", Check: Regex, Options: Options{"pattern": "^[A-Z]{3}$"}},
+		Rule{Param: "role", Check: In, Options: Options{"values": []string{"admin", "viewer"}}},
+		Rule{Param: "address.city", Check: Required},
+		Rule{Param: "items.*.sku", Check: Alphanumeric},
+		Rule{Param: "tags", Check: MaxItems, Options: Options{"count": 3}},
+		Rule{Param: "page", Source: Query, Check: Integer},
+	)
+
+	schemaJSON(t, schema, `{
+		"type": "object",
+		"required": ["email"],
+		"properties": {
+			"email": {"type": "string", "format": "email", "maxLength": 255},
+			"age": {"type": "integer"},
+			"code": {"pattern": "^[A-Z]{3}$"},
+			"role": {"enum": ["admin", "viewer"]},
+			"address": {"type": "object", "required": ["city"], "properties": {"city": {}}},
+			"items": {"type": "array", "items": {"type": "object", "properties": {"sku": {"type": "string", "pattern": "^[a-zA-Z0-9]*$"}}}},
+			"tags": {"maxItems": 3}
+		}
+	}`)
+}
+
+func TestRegisterSchema(t *testing.T) {
+	var even CheckFunc = func(r *http.Request, param string, _ Options) error {
+		return errors.New("not implemented")
+	}
+	RegisterSchema("even", func(Options) Schema {
+		return Schema{"type": "integer", "multipleOf": 2}
+	})
+
+	schemaJSON(t, SchemaFor(Rule{Param: "n", Check: even, Name: "even"}), `{
+		"type": "object",
+		"properties": {"n": {"type": "integer", "multipleOf": 2}}
+	}`)
+}
+
+func TestRegisterSchemaByName(t *testing.T) {
+	prefix := func(p string) CheckFunc {
+		return FieldFunc(func(ctx context.Context, f Field) error {
+			if !strings.HasPrefix(f.String(), p) {
+				return fmt.Errorf("%s must start with %s", f.Name, p)
+			}
+			return nil
+		}).CheckFunc()
+	}
+	RegisterSchema("sku", func(Options) Schema {
+		return Schema{"type": "string", "pattern": "^SKU-"}
+	})
+
+	// Closures made by the same function literal share their code,
+	// so only the Name tells them apart.
+	schemaJSON(t, SchemaFor(
+		Rule{Param: "sku", Check: prefix("SKU-"), Name: "sku"},
+		Rule{Param: "iban", Check: prefix("GB")},
+		Rule{Param: "ids", Check: Each(Integer)},
+	), `{
+		"type": "object",
+		"properties": {"sku": {"type": "string", "pattern": "^SKU-"}, "iban": {}, "ids": {}}
+	}`)
+}
+
+func TestOpenAPI(t *testing.T) {
+	v := Make(&http.Request{Header: http.Header{}},
+		Rule{Param: "id", Source: Path, Check: Integer},
+		Rule{Param: "page", Source: Query, Check: Integer},
+		Rule{Param: "X-Api-Key", Source: Header, Check: Required},
+		Rule{Param: "name", Source: Body, Check: Required},
+	)
+
+	schemaJSON(t, v.OpenAPI(), `{
+		"parameters": [
+			{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}},
+			{"name": "page", "in": "query", "schema": {"type": "integer"}},
+			{"name": "X-Api-Key", "in": "header", "required": true, "schema": {}}
+		],
+		"requestBody": {
+			"required": true,
+			"content": {
+				"application/json": {"schema": {"type": "object", "required": ["name"], "properties": {"name": {}}}},
+				"application/x-www-form-urlencoded": {"schema": {"type": "object", "required": ["name"], "properties": {"name": {}}}}
+			}
+		}
+	}`)
+
+	op := OpenAPI(Rule{Param: "avatar", Check: Image})
+	if _, ok := op.RequestBody.Content["multipart/form-data"]; !ok {
+		fmt.Println("expected a multipart body for a file rule, got", op.RequestBody.Content)
+		t.FailNow()
+	}
+}
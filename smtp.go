@@ -0,0 +1,211 @@
+package validate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+// SMTPStatus is the outcome of asking a mail server whether it will
+// accept email for an address.
+type SMTPStatus int
+
+// The possible SMTP verification outcomes.
+const (
+	// SMTPUnknown means no mail server gave a definitive answer.
+	SMTPUnknown SMTPStatus = iota
+	// SMTPValid means the server accepted the recipient.
+	SMTPValid
+	// SMTPInvalid means the server permanently rejected the recipient.
+	SMTPInvalid
+	// SMTPGreylisted means the server temporarily rejected the
+	// recipient, and asked for the message to be sent again later.
+	SMTPGreylisted
+)
+
+func (s SMTPStatus) String() string {
+	switch s {
+	case SMTPValid:
+		return "valid"
+	case SMTPInvalid:
+		return "invalid"
+	case SMTPGreylisted:
+		return "greylisted"
+	}
+
+	return "unknown"
+}
+
+// SMTPResult describes the answer a mail server gave for an address.
+type SMTPResult struct {
+	Status SMTPStatus
+	// CatchAll is true when the server also accepted a randomly
+	// generated recipient, so accepts mail for any address.
+	CatchAll bool
+	// Host is the mail server that gave the answer.
+	Host string
+	// Code and Message are the server's reply to the recipient.
+	Code    int
+	Message string
+}
+
+// SMTPVerifier checks whether an email address exists by connecting
+// to the domain's mail servers and starting, but never completing,
+// the delivery of a message.
+type SMTPVerifier struct {
+	// HeloName is the host name sent in the EHLO/HELO command. It
+	// should resolve to the host running the verifier, as many
+	// servers reject names that do not.
+	HeloName string
+	// MailFrom is the sender address sent in the MAIL FROM command.
+	// If it is empty, the null sender `<>` is used.
+	MailFrom string
+	// Port is the port that mail servers are contacted on, which
+	// defaults to 25.
+	Port string
+	// Resolver finds the mail servers for a domain. If it is nil,
+	// DefaultResolver is used.
+	Resolver Resolver
+	// Timeout limits the whole verification when the context has
+	// no deadline. It defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// Verify asks the mail servers for the address' domain, in order of
+// preference, whether they accept mail for the address. The next
+// server is only tried if one cannot be reached, or does not give a
+// definitive answer for the recipient. An error is returned along
+// with an SMTPUnknown result if no server could be asked.
+func (v *SMTPVerifier) Verify(ctx context.Context, address string) (SMTPResult, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := v.Timeout
+		if timeout == 0 {
+			timeout = 10 * time.Second
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	rsv := v.Resolver
+	if rsv == nil {
+		rsv = DefaultResolver
+	}
+
+	domain := getDomain(address)
+	hosts, err := mailHosts(ctx, rsv, domain)
+	if err == errNullMX {
+		return SMTPResult{Status: SMTPInvalid}, nil
+	}
+	if err != nil {
+		return SMTPResult{}, err
+	}
+
+	lastErr := errors.New("no mail hosts")
+	for _, host := range hosts {
+		result, err := v.verifyHost(ctx, host, address, domain)
+		if err == nil {
+			return result, nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return SMTPResult{}, fmt.Errorf("unable to verify %s: %w", address, lastErr)
+}
+
+func (v *SMTPVerifier) verifyHost(ctx context.Context, host string, address string, domain string) (SMTPResult, error) {
+	port := v.Port
+	if port == "" {
+		port = "25"
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return SMTPResult{}, err
+	}
+	defer conn.Close()
+
+	// Reads and writes share the context's deadline, and the
+	// connection is closed early if the context is cancelled.
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return SMTPResult{}, err
+	}
+	defer c.Quit()
+
+	helo := v.HeloName
+	if helo == "" {
+		helo = "localhost"
+	}
+
+	if err := c.Hello(helo); err != nil {
+		return SMTPResult{}, err
+	}
+
+	if err := c.Mail(v.MailFrom); err != nil {
+		return SMTPResult{}, err
+	}
+
+	result := rcpt(c, address)
+	result.Host = host
+	if result.Status == SMTPUnknown {
+		return result, fmt.Errorf("%s did not answer for the recipient: %s", host, result.Message)
+	}
+
+	if result.Status == SMTPValid {
+		random := make([]byte, 8)
+		rand.Read(random)
+		probe := rcpt(c, "validate-"+hex.EncodeToString(random)+"@"+domain)
+		result.CatchAll = probe.Status == SMTPValid
+	}
+
+	return result, nil
+}
+
+// rcpt sends a RCPT TO command and classifies the reply.
+func rcpt(c *smtp.Client, address string) SMTPResult {
+	err := c.Rcpt(address)
+	if err == nil {
+		return SMTPResult{Status: SMTPValid, Code: 250}
+	}
+
+	var reply *textproto.Error
+	if !errors.As(err, &reply) {
+		return SMTPResult{Status: SMTPUnknown, Message: err.Error()}
+	}
+
+	result := SMTPResult{Code: reply.Code, Message: reply.Msg}
+	switch reply.Code {
+	case 550, 551, 553:
+		result.Status = SMTPInvalid
+	case 450, 451, 452:
+		result.Status = SMTPGreylisted
+	}
+
+	return result
+}
@@ -0,0 +1,198 @@
+package validate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTP is a mail server that answers RCPT commands from a
+// fixed set of replies.
+type fakeSMTP struct {
+	listener net.Listener
+	replies  map[string]string
+	catchAll bool
+	silent   bool
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func (s *fakeSMTP) start(t *testing.T) *fakeSMTP {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("unable to listen on loopback:", err)
+	}
+
+	s.listener = l
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTP) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTP) handle(conn net.Conn) {
+	defer conn.Close()
+	if s.silent {
+		time.Sleep(3 * time.Second)
+		return
+	}
+
+	fmt.Fprint(conn, "220 fake.test ESMTP\r\n")
+	lines := bufio.NewReader(conn)
+	for {
+		line, err := lines.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO", "MAIL", "RSET", "NOOP":
+			fmt.Fprint(conn, "250 OK\r\n")
+		case "RCPT":
+			address := line[strings.Index(line, "<")+1 : strings.LastIndex(line, ">")]
+			reply, ok := s.replies[address]
+			switch {
+			case ok:
+				fmt.Fprint(conn, reply+"\r\n")
+			case s.catchAll:
+				fmt.Fprint(conn, "250 OK\r\n")
+			default:
+				fmt.Fprint(conn, "550 5.1.1 No such user\r\n")
+			}
+		case "QUIT":
+			fmt.Fprint(conn, "221 Bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "502 Unknown command\r\n")
+		}
+	}
+}
+
+func (s *fakeSMTP) sent(prefix string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.commands {
+		if strings.HasPrefix(c, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// smtpResolver points fake.test at the fake server, with an
+// unreachable server preferred over it.
+var smtpResolver = StaticResolver{
+	MX: map[string][]*net.MX{
+		"fake.test": {{Host: "127.0.0.1.", Pref: 20}, {Host: "127.0.0.2.", Pref: 10}},
+	},
+}
+
+func TestTelnetEmailAgainstFakeServer(t *testing.T) {
+	s := (&fakeSMTP{replies: map[string]string{
+		"me@fake.test":    "250 2.1.5 OK",
+		"grey@fake.test":  "451 4.7.1 Greylisted, try again later",
+		"short@fake.test": "45",
+	}}).start(t)
+	defer s.listener.Close()
+
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+
+	options := Options{"port": s.port(), "helo": "mail.example.com", "from": "check@example.com", "timeout": 2}
+
+	cases := []struct {
+		Value   string
+		Passes  bool
+		Options Options
+	}{
+		{"me@fake.test", true, options},
+		{"nobody@fake.test", false, options},
+		{"grey@fake.test", false, options},
+		{"short@fake.test", false, options},
+		{"me@unknown.test", false, options},
+		{"grey@fake.test", true, Options{"port": s.port(), "allow_unknown": true}},
+		{"short@fake.test", true, Options{"port": s.port(), "allow_unknown": true}},
+	}
+
+	for _, c := range cases {
+		r.Form.Set("email", c.Value)
+		v := Make(r, Rule{Param: "email", Check: TelnetEmail, Options: c.Options})
+		v.Resolver = smtpResolver
+		msgs, _ := v.Run()
+		if c.Passes != (len(msgs) == 0) {
+			fmt.Println("unexpected result for", c.Value, msgs)
+			t.FailNow()
+		}
+	}
+
+	if !s.sent("EHLO mail.example.com") || !s.sent("MAIL FROM:<check@example.com>") {
+		fmt.Println("expected the configured HELO name and sender to be used")
+		t.FailNow()
+	}
+}
+
+func TestSMTPVerifierDetectsCatchAll(t *testing.T) {
+	s := (&fakeSMTP{catchAll: true}).start(t)
+	defer s.listener.Close()
+
+	v := &SMTPVerifier{Port: s.port(), Resolver: smtpResolver}
+	result, err := v.Verify(context.Background(), "anyone@fake.test")
+	if err != nil || result.Status != SMTPValid || !result.CatchAll || result.Host != "127.0.0.1" {
+		fmt.Println("expected a valid catch-all result, got", result, err)
+		t.FailNow()
+	}
+
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+	r.Form.Set("email", "anyone@fake.test")
+	v2 := Make(r, Rule{Param: "email", Check: TelnetEmail, Options: Options{"port": s.port(), "reject_catch_all": true}})
+	v2.Resolver = smtpResolver
+	if msgs, _ := v2.Run(); len(msgs) == 0 {
+		fmt.Println("expected catch-all addresses to be rejected")
+		t.FailNow()
+	}
+}
+
+func TestSMTPVerifierTimesOut(t *testing.T) {
+	s := (&fakeSMTP{silent: true}).start(t)
+	defer s.listener.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	v := &SMTPVerifier{Port: s.port(), Resolver: smtpResolver}
+	result, err := v.Verify(ctx, "me@fake.test")
+	if err == nil || result.Status != SMTPUnknown || time.Since(start) > 2*time.Second {
+		fmt.Println("expected the verification to time out, got", result, err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,101 @@
+package validate
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Source is the part of the request that a Rule's parameter is read
+// from. Each CheckFunc is given a request whose Form holds only the
+// values from the Rule's Source. Rules that compare fields, such as
+// PostalCode with a `field`, can read the other Sources.
+type Source string
+
+// The sources that a Rule can read its parameter from.
+const (
+	// Form is the default Source. It is the request's Form, which
+	// merges the URL query with a POST, PUT or PATCH body.
+	Form Source = ""
+	// Query is the URL's query string.
+	Query Source = "query"
+	// Body is a url-encoded or multipart form body.
+	Body Source = "body"
+	// Header is the request's headers. Names are not case sensitive.
+	Header Source = "header"
+	// Cookie is the request's cookies.
+	Cookie Source = "cookie"
+	// Path is the wildcards in the pattern that the request was
+	// routed by an http.ServeMux, as returned by r.PathValue.
+	Path Source = "path"
+)
+
+func (s Source) valid() bool {
+	switch s {
+	case Form, Query, Body, Header, Cookie, Path:
+		return true
+	}
+
+	return false
+}
+
+// key returns the name that the Rule's errors and values are
+// reported under. Parameters that are not from the Form are
+// prefixed with their Source, such as `query.page` or
+// `header.X-Request-Id`. Rules that check a whole Source, and so
+// have no Param, are reported under the Source's name.
+func (rule Rule) key() string {
+	if rule.Source == Form || rule.Param == "" {
+		return join(string(rule.Source), rule.Param)
+	}
+
+	return string(rule.Source) + "." + rule.Param
+}
+
+// sourceValues returns a copy of the request's values from the
+// Source. Headers, cookies and path values are only collected for
+// the given params.
+func sourceValues(r *http.Request, s Source, params []string) url.Values {
+	values := make(url.Values)
+
+	switch s {
+	case Form, Body:
+		form := r.Form
+		if s == Body {
+			form = r.PostForm
+		}
+		values = copyValues(form)
+	case Query:
+		values = r.URL.Query()
+	case Header:
+		for _, param := range params {
+			if vs := r.Header.Values(param); len(vs) > 0 {
+				values[param] = append([]string(nil), vs...)
+			}
+		}
+	case Cookie:
+		for _, param := range params {
+			for _, c := range r.Cookies() {
+				if c.Name == param {
+					values[param] = append(values[param], c.Value)
+				}
+			}
+		}
+	case Path:
+		for _, param := range params {
+			if value := r.PathValue(param); value != "" {
+				values[param] = []string{value}
+			}
+		}
+	}
+
+	return values
+}
+
+func copyValues(values url.Values) url.Values {
+	c := make(url.Values, len(values))
+	for param, vs := range values {
+		c[param] = append([]string(nil), vs...)
+	}
+
+	return c
+}
@@ -0,0 +1,79 @@
+package validate
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSources(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost/users/42?page=2&id=query", strings.NewReader("id=body&page=x"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("X-Request-Id", " abc ")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "s3cr3t"})
+	r.SetPathValue("user", "42")
+
+	v := Make(r,
+		Rule{Param: "page", Source: Query, Check: Integer},
+		Rule{Param: "id", Source: Body, Check: In, Options: Options{"values": []string{"body"}}},
+		Rule{Param: "x-request-id", Source: Header, Check: Alphanumeric, Filters: []Filter{Trim}},
+		Rule{Param: "session", Source: Cookie, Check: Required},
+		Rule{Param: "user", Source: Path, Check: Integer},
+	)
+
+	if msgs, _ := v.Run(); len(msgs) > 0 {
+		fmt.Println("unexpected errors:", msgs)
+		t.FailNow()
+	}
+
+	if v.Int("query.page") != 2 || v.Int("path.user") != 42 || v.StringValue("header.x-request-id") != "abc" || v.StringValue("cookie.session") != "s3cr3t" {
+		fmt.Println("unexpected values:", v.Validated())
+		t.FailNow()
+	}
+}
+
+func TestSourcesPrefixMessages(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost?page=x", strings.NewReader("page=2"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	msgs, _ := Check(r,
+		Rule{Param: "page", Source: Query, Check: Integer},
+		Rule{Param: "page", Source: Body, Check: Integer},
+		Rule{Param: "X-Api-Key", Source: Header, Check: Required},
+		Rule{Param: "session", Source: Cookie, Check: Required},
+		Rule{Param: "user", Source: Path, Check: Required},
+	)
+
+	for _, key := range []string{"query.page", "header.X-Api-Key", "cookie.session", "path.user"} {
+		if len(msgs[key]) == 0 {
+			fmt.Println("expected an error for", key, "got", msgs)
+			t.FailNow()
+		}
+	}
+
+	if len(msgs) != 4 {
+		fmt.Println("expected the body's page to pass, got", msgs)
+		t.FailNow()
+	}
+}
+
+func TestRulesReadOtherSources(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost?country=GB", strings.NewReader("postcode=LS1+1AA&project=7"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("X-Projects", "7,8")
+
+	projects := ValueSourceFunc(func(r *http.Request) ([]string, error) {
+		return strings.Split(r.Header.Get("X-Projects"), ","), nil
+	})
+
+	msgs, _ := Check(r,
+		Rule{Param: "country", Source: Query, Check: Country},
+		Rule{Param: "postcode", Source: Body, Check: PostalCode, Options: Options{"field": "query.country"}},
+		Rule{Param: "project", Source: Body, Check: In, Options: Options{"values": projects}},
+	)
+	if len(msgs) > 0 {
+		fmt.Println("unexpected errors:", msgs)
+		t.FailNow()
+	}
+}
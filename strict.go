@@ -0,0 +1,228 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IgnoredFields are never reported as unknown by a strict Validator.
+// They are the names that common frameworks and libraries use for
+// CSRF tokens and HTTP method overrides.
+var IgnoredFields = []string{
+	"_token",
+	"_csrf",
+	"csrf_token",
+	"csrfmiddlewaretoken",
+	"authenticity_token",
+	"gorilla.csrf.Token",
+	"_method",
+}
+
+// fieldPath splits a field name into its path segments. Both dotted
+// names, such as `address.city` or `items.0.name`, and bracketed
+// form names, such as `items[0][name]` or `tags[]`, are understood.
+func fieldPath(name string) []string {
+	name = strings.NewReplacer("][", ".", "[", ".", "]", "").Replace(name)
+
+	var path []string
+	for _, segment := range strings.Split(name, ".") {
+		if segment != "" {
+			path = append(path, segment)
+		}
+	}
+
+	return path
+}
+
+// matchPath reports whether the pattern matches the start of path.
+// A `*` segment in the pattern matches any single segment.
+func matchPath(pattern []string, path []string) bool {
+	if len(pattern) > len(path) {
+		return false
+	}
+
+	for i, segment := range pattern {
+		if segment != "*" && segment != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fieldPatterns are the paths of the fields that a strict Validator
+// permits: those that its form, query and body rules refer to, its
+// AllowedFields and the package's IgnoredFields.
+func (v *Validator) fieldPatterns() [][]string {
+	var patterns [][]string
+	for _, rule := range v.Rules {
+		if rule.Source == Form || rule.Source == Query || rule.Source == Body {
+			patterns = append(patterns, fieldPath(rule.Param))
+		}
+	}
+	for _, field := range v.AllowedFields {
+		patterns = append(patterns, fieldPath(field))
+	}
+	for _, field := range IgnoredFields {
+		patterns = append(patterns, fieldPath(field))
+	}
+
+	return patterns
+}
+
+// permitted reports whether the field at path, or a field that it
+// is inside of, matches one of the patterns.
+func permitted(patterns [][]string, path []string) bool {
+	for _, pattern := range patterns {
+		if len(pattern) > 0 && matchPath(pattern, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mayContain reports whether any of the patterns could permit a
+// field inside of the one at path.
+func mayContain(patterns [][]string, path []string) bool {
+	for _, pattern := range patterns {
+		if len(pattern) > len(path) && matchPath(pattern[:len(path)], path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unknownFields returns the names of the fields in the request's
+// form and JSON body that the Validator does not permit.
+func (v *Validator) unknownFields(r *http.Request) ([]string, error) {
+	patterns := v.fieldPatterns()
+	var unknown []string
+
+	names := make([]string, 0, len(r.Form))
+	for name := range r.Form {
+		names = append(names, name)
+	}
+	if mf := r.MultipartForm; mf != nil {
+		for name := range mf.File {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		if !permitted(patterns, fieldPath(name)) {
+			unknown = append(unknown, name)
+		}
+	}
+
+	body, err := jsonBody(r, v.maxBodySize())
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		unknown = append(unknown, unknownJSON(patterns, nil, body)...)
+	}
+
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// unknownJSON walks a decoded JSON value and returns the paths of
+// the outermost values that the patterns do not permit.
+func unknownJSON(patterns [][]string, path []string, value interface{}) []string {
+	if len(path) > 0 && permitted(patterns, path) {
+		return nil
+	}
+
+	// An object or array is known if fields inside of it are, even
+	// when it is empty, but other values are only known if they are
+	// permitted themselves.
+	var children map[string]interface{}
+	container := false
+	switch value := value.(type) {
+	case map[string]interface{}:
+		children, container = value, true
+	case []interface{}:
+		children, container = make(map[string]interface{}, len(value)), true
+		for i, child := range value {
+			children[strconv.Itoa(i)] = child
+		}
+	}
+
+	if len(path) > 0 && (!container || !mayContain(patterns, path)) {
+		return []string{strings.Join(path, ".")}
+	}
+
+	var unknown []string
+	for key, child := range children {
+		childPath := append(append([]string(nil), path...), key)
+		unknown = append(unknown, unknownJSON(patterns, childPath, child)...)
+	}
+
+	return unknown
+}
+
+// DefaultMaxBodySize is the number of bytes of a JSON body that a
+// Validator reads when its MaxBodySize is 0.
+const DefaultMaxBodySize int64 = 1 << 20
+
+// maxBodySize returns the Validator's MaxBodySize, or the default.
+func (v *Validator) maxBodySize() int64 {
+	if v.MaxBodySize > 0 {
+		return v.MaxBodySize
+	}
+
+	return DefaultMaxBodySize
+}
+
+// body is a request body that has been partly read, which returns
+// the bytes that were read before the rest of the original body.
+type body struct {
+	io.Reader
+	io.Closer
+}
+
+// jsonBody decodes the request's body if it is JSON, and replaces
+// the body so that it can still be read by the handler. It returns
+// nil if the request does not have a JSON body, and an error if the
+// body is longer than limit bytes.
+func jsonBody(r *http.Request, limit int64) (interface{}, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if r.Body == nil || mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		return nil, fmt.Errorf("unable to read the request body: %w", err)
+	}
+
+	if int64(len(data)) > limit {
+		r.Body = body{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+		return nil, fmt.Errorf("body cannot be larger than %s", formatSize(limit))
+	}
+
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("body must be valid JSON")
+	}
+
+	return body, nil
+}
@@ -0,0 +1,130 @@
+package validate
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestStrictForm(t *testing.T) {
+	cases := []struct {
+		Form    url.Values
+		Allowed []string
+		Unknown []string
+	}{
+		{url.Values{"name": {"Tom"}}, nil, nil},
+		{url.Values{"name": {"Tom"}, "is_admin": {"1"}}, nil, []string{"is_admin"}},
+		{url.Values{"name": {"Tom"}, "_token": {"abc"}, "csrf_token": {"abc"}}, nil, nil},
+		{url.Values{"name": {"Tom"}, "page": {"2"}}, []string{"page"}, nil},
+		{url.Values{"name": {"Tom"}, "address[city]": {"Leeds"}}, []string{"address"}, nil},
+		{url.Values{"name": {"Tom"}, "items[0][name]": {"a"}, "items[1][name]": {"b"}}, []string{"items.*.name"}, nil},
+		{url.Values{"name": {"Tom"}, "items[0][price]": {"1"}}, []string{"items.*.name"}, []string{"items[0][price]"}},
+		{url.Values{"name": {"Tom"}, "tags[]": {"a", "b"}}, []string{"tags"}, nil},
+	}
+
+	for _, c := range cases {
+		r, _ := http.NewRequest("POST", "localhost", strings.NewReader(c.Form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		v := Make(r, Rule{Param: "name", Check: Required})
+		v.Strict = true
+		v.AllowedFields = c.Allowed
+
+		checkUnknown(t, v, c.Unknown)
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	cases := []struct {
+		Body    string
+		Allowed []string
+		Unknown []string
+	}{
+		{`{"name": "Tom"}`, nil, nil},
+		{`{"name": "Tom", "is_admin": true}`, nil, []string{"is_admin"}},
+		{`{"name": "Tom", "address": {"city": "Leeds", "admin": true}}`, []string{"address.city"}, []string{"address.admin"}},
+		{`{"name": "Tom", "profile": {"role": {"admin": true}}}`, nil, []string{"profile"}},
+		{`{"name": "Tom", "items": [{"name": "a"}, {"name": "b", "price": 1}]}`, []string{"items.*.name"}, []string{"items.1.price"}},
+		{`{"name": "Tom", "meta": {"a": 1, "b": [1, 2]}}`, []string{"meta"}, nil},
+		{`{"name": "Tom", "items": []}`, []string{"items.*.name"}, nil},
+		{`{"name": "Tom", "address": {}}`, []string{"address.line1"}, nil},
+		{`{"name": "Tom", "address": "Leeds"}`, []string{"address.line1"}, []string{"address"}},
+		{`{"name": "Tom", "profile": {}}`, nil, []string{"profile"}},
+	}
+
+	for _, c := range cases {
+		r, _ := http.NewRequest("POST", "localhost", strings.NewReader(c.Body))
+		r.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+		v := Make(r, Rule{Param: "name", Check: Empty})
+		v.Strict = true
+		v.AllowedFields = c.Allowed
+
+		checkUnknown(t, v, c.Unknown)
+
+		if body, _ := io.ReadAll(r.Body); string(body) != c.Body {
+			fmt.Println("expected the body to still be readable, got", string(body))
+			t.FailNow()
+		}
+	}
+}
+
+func TestStrictRejectsInvalidJSON(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", strings.NewReader(`{"name": `))
+	r.Header.Set("Content-Type", "application/json")
+
+	v := Make(r, Rule{Param: "name", Check: Empty})
+	v.Strict = true
+
+	if msgs, _ := v.Run(); len(msgs["body"]) == 0 {
+		fmt.Println("expected an error for the body, got", msgs)
+		t.FailNow()
+	}
+}
+
+func TestStrictLimitsTheBody(t *testing.T) {
+	body := `{"name": "` + strings.Repeat("a", 100) + `"}`
+	r, _ := http.NewRequest("POST", "localhost", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+
+	v := Make(r, Rule{Param: "name", Check: Empty})
+	v.Strict = true
+	v.MaxBodySize = 64
+
+	msgs, _ := v.Run()
+	if len(msgs["body"]) != 1 || msgs["body"][0] != "body cannot be larger than 64 bytes" {
+		fmt.Println("expected the body to be too large, got", msgs)
+		t.FailNow()
+	}
+
+	if got, _ := io.ReadAll(r.Body); string(got) != body {
+		fmt.Println("expected the body to still be readable, got", string(got))
+		t.FailNow()
+	}
+}
+
+// checkUnknown runs the Validator and checks that the only fields
+// reported as not allowed are the unknown ones.
+func checkUnknown(t *testing.T, v *Validator, unknown []string) {
+	msgs, _ := v.Run()
+
+	var got []string
+	for field, errs := range msgs {
+		for _, err := range errs {
+			if strings.HasSuffix(err, "is not an allowed field") {
+				got = append(got, field)
+			}
+		}
+	}
+	sort.Strings(got)
+
+	if !reflect.DeepEqual(got, unknown) {
+		fmt.Println("expected unknown fields", unknown, "got", got)
+		t.FailNow()
+	}
+}
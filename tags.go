@@ -0,0 +1,215 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// tagRule is a rule that can be used in a `validate` struct tag. The
+// tag's value, if it has one, is passed under the option key.
+type tagRule struct {
+	Check  CheckFunc
+	Option string
+	Kind   reflect.Kind
+}
+
+// tagRules are the rules that can be named in a `validate` tag.
+// Options of kind Slice are split on `|`.
+var tagRules = map[string]tagRule{
+	"required":         {Required, "", reflect.Invalid},
+	"empty":            {Empty, "", reflect.Invalid},
+	"alpha":            {Alpha, "", reflect.Invalid},
+	"alphanumeric":     {Alphanumeric, "", reflect.Invalid},
+	"integer":          {Integer, "", reflect.Invalid},
+	"boolean":          {Boolean, "", reflect.Invalid},
+	"max_length":       {MaxLength, "length", reflect.Int},
+	"min_length":       {MinLength, "length", reflect.Int},
+	"regex":            {Regex, "pattern", reflect.String},
+	"not_regex":        {NotRegex, "pattern", reflect.String},
+	"email":            {Email, "mode", reflect.String},
+	"mx_email":         {MXEmail, "", reflect.Invalid},
+	"disposable_email": {DisposableEmail, "", reflect.Invalid},
+	"rfc3339":          {RFC3339, "", reflect.Invalid},
+	"date":             {Date, "", reflect.Invalid},
+	"date_only":        {DateOnly, "mode", reflect.String},
+	"time_only":        {TimeOnly, "mode", reflect.String},
+	"date_time":        {DateTime, "mode", reflect.String},
+	"duration":         {Duration, "mode", reflect.String},
+	"before":           {Before, "date", reflect.String},
+	"after":            {After, "date", reflect.String},
+	"min_age":          {MinAge, "age", reflect.Int},
+	"max_age":          {MaxAge, "age", reflect.Int},
+	"business_day":     {BusinessDay, "", reflect.Invalid},
+	"country":          {Country, "format", reflect.String},
+	"language":         {Language, "", reflect.Invalid},
+	"timezone":         {Timezone, "", reflect.Invalid},
+	"postal_code":      {PostalCode, "country", reflect.String},
+	"phone":            {Phone, "region", reflect.String},
+	"in":               {In, "values", reflect.Slice},
+	"not_in":           {NotIn, "values", reflect.Slice},
+	"in_fold":          {InFold, "values", reflect.Slice},
+	"not_in_fold":      {NotInFold, "values", reflect.Slice},
+	"list":             {List, "", reflect.Invalid},
+	"min_items":        {MinItems, "count", reflect.Int},
+	"max_items":        {MaxItems, "count", reflect.Int},
+	"distinct":         {Distinct, "", reflect.Invalid},
+}
+
+// RulesFor returns the Rules described by the `validate` tags on the
+// fields of a struct, or a pointer to one. Fields are named in the
+// same way as by Validate, and nested structs are walked. A tag is a
+// comma separated list of rules, in snake case, with an optional
+// value for the rule's main option:
+//
+//	Email string   `json:"email" validate:"required,email,max_length=255"`
+//	Role  string   `json:"role" validate:"in=admin|editor|viewer"`
+//	Tags  []string `json:"tags" validate:"list,max_items=5,distinct"`
+//
+// Values cannot contain commas. Rules that need other options, or
+// that are not built in, must be added to the Validator directly.
+//
+// The validategen command generates a Validate method for tagged
+// structs, which gives the same messages without using reflection.
+func RulesFor(v interface{}) ([]Rule, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unable to read rules from type %T", v)
+	}
+
+	return structRules("", t)
+}
+
+func structRules(prefix string, t reflect.Type) ([]Rule, error) {
+	var rules []Rule
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !(sf.Anonymous && sf.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		name, tagged := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if sf.Anonymous && !tagged && ft.Kind() == reflect.Struct {
+			embedded, err := structRules(prefix, ft)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, embedded...)
+			continue
+		}
+
+		param := join(prefix, name)
+		if tag, ok := sf.Tag.Lookup("validate"); ok {
+			fieldRules, err := parseTag(param, tag)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", sf.Name, err)
+			}
+			rules = append(rules, fieldRules...)
+		}
+
+		if ft.Kind() == reflect.Struct && !isScalarType(ft) {
+			nested, err := structRules(param, ft)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, nested...)
+		}
+	}
+
+	return rules, nil
+}
+
+// isScalarType reports whether a struct type is flattened to a single
+// value by Validate, such as time.Time.
+func isScalarType(t reflect.Type) bool {
+	_, ok := scalar(reflect.Zero(t))
+	return ok
+}
+
+// ParseTag returns the Rules described by a `validate` tag for the
+// parameter. See RulesFor for the tag's format.
+func ParseTag(param string, tag string) ([]Rule, error) {
+	return parseTag(param, tag)
+}
+
+// MustParseTags is like ParseTag, but takes pairs of parameters and
+// tags, and panics if a tag is invalid. It is used by the code that
+// validategen generates.
+func MustParseTags(pairs ...string) []Rule {
+	if len(pairs)%2 != 0 {
+		panic("validate: MustParseTags needs pairs of parameters and tags")
+	}
+
+	var rules []Rule
+	for i := 0; i < len(pairs); i += 2 {
+		tagRules, err := parseTag(pairs[i], pairs[i+1])
+		if err != nil {
+			panic(fmt.Sprintf("validate: field %s: %v", pairs[i], err))
+		}
+		rules = append(rules, tagRules...)
+	}
+
+	return rules
+}
+
+// parseTag parses the rules in a `validate` tag.
+func parseTag(param string, tag string) ([]Rule, error) {
+	var rules []Rule
+	for _, item := range strings.Split(tag, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(item, "=")
+		tr, ok := tagRules[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+
+		rule := Rule{Param: param, Check: tr.Check, Name: name}
+		if hasValue {
+			if tr.Option == "" {
+				return nil, fmt.Errorf("rule %q does not take a value", name)
+			}
+
+			option, err := tagOption(tr.Kind, value)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", name, err)
+			}
+			rule.Options = Options{tr.Option: option}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func tagOption(kind reflect.Kind, value string) (interface{}, error) {
+	switch kind {
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", value)
+		}
+		return n, nil
+	case reflect.Slice:
+		return strings.Split(value, "|"), nil
+	}
+
+	return value, nil
+}
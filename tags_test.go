@@ -0,0 +1,73 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type tagged struct {
+	Email   string    `json:"email" validate:"required,email,max_length=255"`
+	Role    string    `json:"role" validate:"in=admin|editor"`
+	Tags    []string  `json:"tags" validate:"max_items=2,distinct"`
+	Joined  time.Time `json:"joined" validate:"date_time"`
+	Address struct {
+		Country string `json:"country" validate:"country=alpha3"`
+	} `json:"address"`
+	Ignored string `json:"-" validate:"required"`
+}
+
+func TestRulesFor(t *testing.T) {
+	rules, err := RulesFor(&tagged{})
+	if err != nil {
+		fmt.Println("unexpected error:", err)
+		t.FailNow()
+	}
+
+	var params []string
+	for _, rule := range rules {
+		params = append(params, rule.Param)
+	}
+	if fmt.Sprint(params) != "[email email email role tags tags joined address.country]" {
+		fmt.Println("unexpected rules for", params)
+		t.FailNow()
+	}
+
+	if rules[2].Options["length"] != 255 || fmt.Sprint(rules[3].Options["values"]) != "[admin editor]" {
+		fmt.Println("unexpected options:", rules[2].Options, rules[3].Options)
+		t.FailNow()
+	}
+
+	s := tagged{Email: "me@tomm.us", Role: "admin", Tags: []string{"a", "a"}, Joined: time.Now()}
+	s.Address.Country = "GBR"
+	msgs, _ := Validate(context.Background(), s, rules...)
+	if len(msgs) != 1 || len(msgs["tags.1"]) == 0 {
+		fmt.Println("expected only the duplicate tag to fail, got", msgs)
+		t.FailNow()
+	}
+
+	schemaJSON(t, SchemaFor(rules...)["required"], `["email"]`)
+}
+
+func TestRulesForErrors(t *testing.T) {
+	cases := []interface{}{
+		"not a struct",
+		struct {
+			A string `validate:"unknown"`
+		}{},
+		struct {
+			A string `validate:"max_length=ten"`
+		}{},
+		struct {
+			A string `validate:"required=yes"`
+		}{},
+	}
+
+	for _, c := range cases {
+		if _, err := RulesFor(c); err == nil {
+			fmt.Printf("expected an error for %#v\n", c)
+			t.FailNow()
+		}
+	}
+}
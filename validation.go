@@ -3,7 +3,10 @@ package validate
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 )
 
 // Validator is responsible for collecting an http.Request and
@@ -12,6 +15,45 @@ import (
 type Validator struct {
 	request *http.Request
 	Rules   []Rule
+	// Resolver is used by rules that look up DNS records, such as
+	// MXEmail. If it is nil, DefaultResolver is used.
+	Resolver Resolver
+	// AllowedDomains and BlockedDomains are checked by
+	// DisposableEmail before the embedded list of disposable
+	// email domains.
+	AllowedDomains []string
+	BlockedDomains []string
+	// UpdateForm writes the values produced by the rules' Filters
+	// back to the request's Form when the Validator is run. By
+	// default, the request is left unchanged and the filtered
+	// values are only available from Filtered.
+	UpdateForm bool
+	// Strict makes Run fail if the request's form or JSON body has
+	// fields that none of the rules refer to, such as an unexpected
+	// `is_admin`. Fields that are in AllowedFields or IgnoredFields
+	// are permitted.
+	Strict bool
+	// AllowedFields are fields that a strict Validator permits even
+	// though no rule refers to them. Nested fields can be given as
+	// `address.city` or `address[city]`, and a `*` matches any one
+	// segment, as in `items.*.name`. Permitting a field permits
+	// everything inside of it.
+	AllowedFields []string
+	// MaxBodySize is the number of bytes of a JSON body that Strict
+	// mode and JSONSchema rules read. Larger bodies fail validation.
+	// If it is 0, DefaultMaxBodySize is used.
+	MaxBodySize int64
+	// MaxMemory is the number of bytes of a multipart/form-data
+	// request that are stored in memory when it is parsed. The rest
+	// of the files are stored in temporary files on disk. If it is
+	// 0, DefaultMaxMemory is used.
+	MaxMemory int64
+
+	filtered  map[Source]url.Values
+	validated map[string]interface{}
+	// fieldFuncs are the FieldFuncs of the Rules, when they have
+	// been compiled. See fieldFunc.
+	fieldFuncs []FieldFunc
 }
 
 // Respond is a helper method that writes the errors to the given
@@ -37,12 +79,10 @@ func Check(r *http.Request, rule ...Rule) (Message, error) {
 
 // Make creates a new Validator based on the request and rules
 // passed into it. The rules argument is optional. Rules can
-// be added by calling `Add` on the returned Validator.
+// be added by calling `Add` on the returned Validator. The
+// request's form is not parsed until the Validator is run, so
+// that its MaxMemory can be set first.
 func Make(r *http.Request, rule ...Rule) *Validator {
-	if r.Form == nil {
-		r.ParseForm()
-	}
-
 	return &Validator{
 		request: r,
 		Rules:   rule,
@@ -50,19 +90,153 @@ func Make(r *http.Request, rule ...Rule) *Validator {
 }
 
 // Run determines if the given rules are satisfied by the request.
+// If they are not, it returns the Message and ValidationFailed. A
+// rule that is misconfigured, such as Regex without a `pattern`,
+// makes Run return a *RuleError that matches ErrMisconfiguredRule
+// instead, or several joined together, and no Message. A rule that
+// could not be checked, such as In with a failing ValueSource,
+// returns a *CheckError that matches ErrCheckFailed in the same way.
+//
+// The request's form is parsed first if it has not been, using
+// MaxMemory for multipart forms so that uploaded files can be
+// validated. If the body cannot be read or parsed, such as when a
+// multipart form is malformed or too large, Run returns that error.
+// Invalid escapes in the query or a urlencoded body are not an
+// error; the values that could be parsed are validated.
 func (v *Validator) Run() (Message, error) {
 	if len(v.Rules) == 0 {
 		return nil, EmptyRuleset
 	}
+	if err := v.parseForm(); err != nil {
+		return nil, err
+	}
 
-	vm := make(Message)
+	rn := &run{ctx: v.request.Context(), settings: v, request: v.request, values: v.requestSources()}
+	msgs, err := v.check(rn)
+
+	v.filtered, v.validated = rn.values, nil
+	if err == nil {
+		v.validated = rn.validatedValues(v.Rules)
+	}
 
+	return msgs, err
+}
+
+// requestSources returns a copy of the values from each of the
+// Sources that the rules read from the request.
+func (v *Validator) requestSources() map[Source]url.Values {
+	params := map[Source][]string{Form: nil}
 	for _, rule := range v.Rules {
-		if err := rule.Check(v.request, rule.Param, rule.Options); err != nil {
-			vm[rule.Param] = append(vm[rule.Param], err.Error())
+		params[rule.Source] = append(params[rule.Source], rule.Param)
+	}
+
+	sources := make(map[Source]url.Values, len(params))
+	for source, ps := range params {
+		sources[source] = sourceValues(v.request, source, ps)
+	}
+
+	return sources
+}
+
+// fieldFunc returns the FieldFunc of the i'th rule, using the one
+// found by Compile if the rules were compiled, as looking it up
+// needs reflection.
+func (v *Validator) fieldFunc(i int) (FieldFunc, bool) {
+	if v.fieldFuncs != nil {
+		return v.fieldFuncs[i], v.fieldFuncs[i] != nil
+	}
+
+	return v.Rules[i].Check.FieldFunc()
+}
+
+// check runs the rules against the values from each Source, each
+// after applying its own Filters. It is shared by Run and Validate,
+// and keeps the state of the run in rn rather than the Validator.
+// Built in rules are given a Field, and other CheckFuncs a request
+// whose Form holds the values of the rule's Source.
+func (v *Validator) check(rn *run) (Message, error) {
+	vm := make(Message)
+
+	rn.lists = v.mergeLists(rn.values, rn.listed)
+	rn.sent = rn.values
+	rn.values = v.filteredValues(rn.sent)
+
+	rn.parsed = make(map[string]interface{})
+	// Validate has no request until a rule needs one, and never
+	// updates the data it is given.
+	if v.UpdateForm && rn.request != nil {
+		for _, rule := range v.Rules {
+			if values, ok := rn.values[Form][rule.Param]; ok && rule.Source == Form && len(rule.Filters) > 0 {
+				rn.request.Form[rule.Param] = values
+			}
 		}
 	}
 
+	var errs []error
+	for i, rule := range v.Rules {
+		if !rule.Source.valid() {
+			errs = append(errs, ruleError(rule, fmt.Errorf("unable to read source %q", rule.Source)))
+			continue
+		}
+
+		// Rules can reach the run's state and the Validator's
+		// settings through their context, and check the filtered
+		// values of their Source.
+		ctx := context.WithValue(rn.ctx, runKey, &ruleRun{rn, rule.Source})
+		form := rn.sent[rule.Source]
+		if values, ok := form[rule.Param]; ok && len(rule.Filters) > 0 {
+			form = withValues(form, rule.Param, filter(values, rule.Filters))
+		}
+
+		var err error
+		if fn, ok := v.fieldFunc(i); ok {
+			err = fn(ctx, rn.field(rule, form))
+		} else {
+			sr := rn.httpRequest().WithContext(ctx)
+			sr.Form = form
+			err = rule.Check(sr, rule.Param, rule.Options)
+		}
+
+		var items ItemErrors
+		var paths PathErrors
+		if errors.Is(err, ErrMisconfiguredRule) {
+			errs = append(errs, ruleError(rule, err))
+		} else if errors.Is(err, ErrCheckFailed) {
+			errs = append(errs, err)
+		} else if errors.As(err, &items) {
+			for _, i := range items.indexes() {
+				key := itemName(rule.key(), i)
+				vm[key] = append(vm[key], items[i].Error())
+			}
+		} else if errors.As(err, &paths) {
+			for _, path := range paths.paths() {
+				key := join(rule.key(), path)
+				vm[key] = append(vm[key], paths[path].Error())
+			}
+		} else if err != nil {
+			vm[rule.key()] = append(vm[rule.key()], err.Error())
+		}
+	}
+
+	if v.Strict {
+		unknown, err := v.unknownFields(rn.httpRequest())
+		if err != nil {
+			vm["body"] = append(vm["body"], err.Error())
+		}
+		for _, field := range unknown {
+			vm[field] = append(vm[field], fmt.Sprintf("%s is not an allowed field", field))
+		}
+	}
+
+	// A misconfigured rule, or one that could not be checked, is a
+	// problem with the application rather than the request, so it
+	// is returned as an error instead of being reported to the user.
+	if len(errs) == 1 {
+		return nil, errs[0]
+	} else if len(errs) > 1 {
+		return nil, errors.Join(errs...)
+	}
+
 	if len(vm) > 0 {
 		return vm, ValidationFailed
 	}
@@ -70,11 +244,75 @@ func (v *Validator) Run() (Message, error) {
 	return nil, nil
 }
 
+// filteredValues returns the values from each Source after the
+// rules' Filters have been applied. Each rule's Filters are applied
+// to the values that were sent, so where several rules for a
+// parameter have Filters, the last of them decides its value.
+// Sources that no rule filters are not copied.
+func (v *Validator) filteredValues(sent map[Source]url.Values) map[Source]url.Values {
+	filtered := make(map[Source]url.Values, len(sent))
+	for source, values := range sent {
+		filtered[source] = values
+	}
+
+	copied := make(map[Source]bool)
+	for _, rule := range v.Rules {
+		values, ok := sent[rule.Source][rule.Param]
+		if !ok || len(rule.Filters) == 0 {
+			continue
+		}
+
+		if !copied[rule.Source] {
+			filtered[rule.Source] = withValues(sent[rule.Source], rule.Param, nil)
+			copied[rule.Source] = true
+		}
+		filtered[rule.Source][rule.Param] = filter(values, rule.Filters)
+	}
+
+	return filtered
+}
+
+// withValues returns a copy of form with the parameter's values
+// replaced, leaving form unchanged.
+func withValues(form url.Values, param string, values []string) url.Values {
+	c := make(url.Values, len(form)+1)
+	for name, vs := range form {
+		c[name] = vs
+	}
+	c[param] = values
+
+	return c
+}
+
+// ruleError returns the RuleError for a misconfigured rule, adding
+// the rule's name to it.
+func ruleError(rule Rule, err error) *RuleError {
+	var re *RuleError
+	if !errors.As(err, &re) {
+		re = &RuleError{Param: rule.key(), Err: err}
+	}
+	if re.Rule == "" {
+		re.Rule = ruleName(rule)
+	}
+
+	return re
+}
+
+// Filtered returns the request's form values as they were checked
+// by the last call to Run, after the rules' Filters were applied.
+// It returns nil if the Validator has not been run. The values of
+// rules with other Sources are returned by Validated.
+func (v *Validator) Filtered() url.Values {
+	return v.filtered[Form]
+}
+
 // Add adds an additional set of rules to the Validator.
 func (v *Validator) Add(rules ...Rule) {
 	v.Rules = append(v.Rules, rules...)
 }
 
+type contextKey string
+
 type Bag string
 
 const ErrorBag Bag = "errorbag"
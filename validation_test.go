@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -176,3 +177,36 @@ func TestParamHasNestedErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestMisconfiguredRulesReturnAnError(t *testing.T) {
+	r, _ := http.NewRequest("GET", "localhost?code=ABC&tag=a&tag=b&joined=2020", nil)
+
+	rules := map[string]Rule{
+		"misconfigured regex rule for code":             {Param: "code", Check: Regex},
+		"misconfigured date_format rule for joined":     {Param: "joined", Check: DateFormat},
+		"misconfigured max_items rule for tag":          {Param: "tag", Check: MaxItems, Options: Options{"count": "1"}},
+		"misconfigured in rule for code":                {Param: "code", Check: In},
+		"misconfigured rule for tag.0":                  {Param: "tag", Check: Each(Regex), Options: Options{"pattern": `[a-`}},
+		"misconfigured required rule for fragment.code": {Param: "code", Source: "fragment", Check: Required},
+	}
+
+	for want, rule := range rules {
+		msgs, err := Check(r, rule, Rule{Param: "missing", Check: Required})
+
+		var re *RuleError
+		if !errors.Is(err, ErrMisconfiguredRule) || !errors.As(err, &re) || !strings.HasPrefix(err.Error(), want) {
+			fmt.Printf("expected %q, got %v\n", want, err)
+			t.FailNow()
+		}
+		if msgs != nil {
+			fmt.Println("expected no messages, got", msgs)
+			t.FailNow()
+		}
+	}
+
+	_, err := Check(r, Rule{Param: "code", Check: Regex}, Rule{Param: "joined", Check: DateFormat})
+	if !errors.Is(err, ErrMisconfiguredRule) || strings.Count(err.Error(), "misconfigured") != 2 {
+		fmt.Println("expected both rules to be reported, got", err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,87 @@
+package validate
+
+import (
+	"context"
+	"time"
+)
+
+// setValue records the value that a rule parsed the parameter as,
+// so that it can be returned by the Validator's Validated method.
+func setValue(ctx context.Context, param string, value interface{}) {
+	if rr := runFor(ctx); rr != nil {
+		rr.parsed[Rule{Param: param, Source: rr.source}.key()] = value
+	}
+}
+
+// Validated returns the values of the parameters that the Validator
+// has rules for, after a call to Run that found no errors. Parameters
+// that are not in the request are left out, as is anything in the
+// request that no rule refers to. Values are keyed in the same way
+// as the Validator's Message, so parameters that are not from the
+// Form are prefixed with their Source, such as `query.page`.
+//
+// Where a rule parsed the value, the parsed value is returned:
+//
+//	Integer                        int
+//	Boolean                        bool
+//	Date, DateFormat, DateOnly,
+//	TimeOnly, DateTime, Before,
+//	After, Between, MinAge, etc.   time.Time
+//	Duration                       ISODuration
+//	Phone                          PhoneNumber
+//
+// Uploaded files are returned as a []*multipart.FileHeader, lists
+// (see List) as a []string, and any other parameter as its string
+// value, after the rules' Filters have been applied. Validated
+// returns nil if the Validator has not been run, or if validation
+// failed.
+func (v *Validator) Validated() map[string]interface{} {
+	return v.validated
+}
+
+// validatedValues collects the values returned by Validated.
+func (rn *run) validatedValues(rules []Rule) map[string]interface{} {
+	validated := make(map[string]interface{})
+	for _, rule := range rules {
+		key := rule.key()
+		if value, ok := rn.parsed[key]; ok {
+			validated[key] = value
+		} else if values, ok := rn.values[rule.Source][rule.Param]; ok && rn.lists[key] {
+			validated[key] = values
+		} else if ok && len(values) > 0 {
+			validated[key] = values[0]
+		} else if mf := rn.request.MultipartForm; mf != nil && len(mf.File[rule.Param]) > 0 && (rule.Source == Form || rule.Source == Body) {
+			validated[key] = mf.File[rule.Param]
+		}
+	}
+
+	return validated
+}
+
+// StringValue returns the validated value of the parameter as a
+// string, or an empty string if it was not validated.
+func (v *Validator) StringValue(param string) string {
+	s, _ := v.validated[param].(string)
+	return s
+}
+
+// Int returns the integer that the Integer rule parsed the parameter
+// as, or 0 if it was not validated as an integer.
+func (v *Validator) Int(param string) int {
+	i, _ := v.validated[param].(int)
+	return i
+}
+
+// Bool returns the value that the Boolean rule parsed the parameter
+// as, or false if it was not validated as a boolean.
+func (v *Validator) Bool(param string) bool {
+	b, _ := v.validated[param].(bool)
+	return b
+}
+
+// Time returns the time that a date or time rule parsed the
+// parameter as, or the zero time if it was not validated as one.
+func (v *Validator) Time(param string) time.Time {
+	t, _ := v.validated[param].(time.Time)
+	return t
+}
@@ -0,0 +1,83 @@
+package validate
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestValidated(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+	r.Form.Set("age", "42")
+	r.Form.Set("dob", "1980-02-29")
+	r.Form.Set("subscribe", "1")
+	r.Form.Set("name", "  Tom ")
+	r.Form.Set("phone", "+44 20 7946 0000")
+	r.Form.Set("is_admin", "true")
+
+	v := Make(r,
+		Rule{Param: "age", Check: Integer},
+		Rule{Param: "dob", Check: Before, Options: Options{"date": "today"}},
+		Rule{Param: "subscribe", Check: Boolean},
+		Rule{Param: "name", Check: Required, Filters: []Filter{Trim}},
+		Rule{Param: "phone", Check: Phone},
+		Rule{Param: "nickname", Check: MaxLength, Options: Options{"length": 10}},
+	)
+
+	if v.Validated() != nil {
+		fmt.Println("expected no values before the Validator is run")
+		t.FailNow()
+	}
+
+	if msgs, _ := v.Run(); len(msgs) > 0 {
+		fmt.Println("unexpected errors:", msgs)
+		t.FailNow()
+	}
+
+	validated := v.Validated()
+	if len(validated) != 5 {
+		fmt.Println("expected only the fields with rules that were sent, got", validated)
+		t.FailNow()
+	}
+
+	if _, ok := validated["is_admin"]; ok {
+		fmt.Println("expected fields without rules to be left out")
+		t.FailNow()
+	}
+
+	if v.Int("age") != 42 || !v.Bool("subscribe") || v.StringValue("name") != "Tom" {
+		fmt.Println("unexpected values:", validated)
+		t.FailNow()
+	}
+
+	if want := time.Date(1980, 2, 29, 0, 0, 0, 0, time.UTC); !v.Time("dob").Equal(want) {
+		fmt.Println("unexpected date of birth:", v.Time("dob"))
+		t.FailNow()
+	}
+
+	if number, ok := validated["phone"].(PhoneNumber); !ok || number.E164() != "+442079460000" {
+		fmt.Println("unexpected phone number:", validated["phone"])
+		t.FailNow()
+	}
+
+	if v.Int("name") != 0 || !v.Time("age").IsZero() {
+		fmt.Println("expected zero values for mismatched types")
+		t.FailNow()
+	}
+}
+
+func TestValidatedIsEmptyAfterFailure(t *testing.T) {
+	r, _ := http.NewRequest("POST", "localhost", nil)
+	r.ParseForm()
+	r.Form.Set("age", "forty")
+
+	v := Make(r, Rule{Param: "age", Check: Integer})
+	v.Run()
+
+	if v.Validated() != nil || v.Int("age") != 0 {
+		fmt.Println("expected no values after validation failed")
+		t.FailNow()
+	}
+}